@@ -17,11 +17,10 @@
 package actions
 
 import (
-	"fmt"
-	"net/http"
-
-	"github.com/czcorpus/cnc-gokit/unireq"
 	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
 	"github.com/czcorpus/wsserver/model"
 	"github.com/czcorpus/wsserver/queries"
 	"github.com/gin-gonic/gin"
@@ -31,16 +30,33 @@ import (
 
 // ActionHandler wraps all the HTTP actions of word-sim-service
 type ActionHandler struct {
-	models   queries.W2VModelProvider
-	searcher *queries.SearchProvider
+	models    queries.W2VModelProvider
+	searcher  *queries.SearchProvider
+	deadlines config.QueryDeadlines
+	httpCache config.HTTPCacheConf
+	authn     *auth.Authenticator
+
+	// rateLimiter is nil in case rate limiting is disabled
+	rateLimiter *RateLimiter
+}
+
+// HandleDatasetList provides listing of all the datasets along with
+// their models, collocation database availability and supported features
+func (a *ActionHandler) HandleDatasetList(ctx *gin.Context) {
+	ans, err := a.searcher.Datasets(ctx.Request.Context())
+	if !err.IsZero() {
+		respondWithError(ctx, err)
+		return
+	}
+	uniresp.WriteJSONResponse(ctx.Writer, ans)
 }
 
 // HandleModelList provides listing of all the configured w2v models for a specified corpus
 func (a *ActionHandler) HandleModelList(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
-	ans, err := a.models.ListModels(corpusID)
-	if err != nil {
-		uniresp.RespondWithErrorJSON(ctx, err, http.StatusInternalServerError)
+	ans, err := a.searcher.ListModels(ctx.Request.Context(), corpusID)
+	if !err.IsZero() {
+		respondWithError(ctx, err)
 		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
@@ -51,19 +67,14 @@ func (a *ActionHandler) HandleModelInfo(ctx *gin.Context) {
 	modelID := ctx.Param("modelId")
 	info, err := a.models.FindModel(corpusID, modelID)
 	if err == model.ErrModelConfNotFound {
-		uniresp.RespondWithErrorJSON(
+		respondWithError(
 			ctx,
-			err,
-			http.StatusNotFound,
+			core.NewAppError("model not found", core.ErrorTypeNotFound, err).WithParam("modelId"),
 		)
 		return
 
 	} else if err != nil {
-		uniresp.RespondWithErrorJSON(
-			ctx,
-			err,
-			http.StatusInternalServerError,
-		)
+		respondWithError(ctx, core.NewAppError("failed to get model", core.ErrorTypeInternalError, err))
 		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, info)
@@ -74,39 +85,40 @@ func (a *ActionHandler) WordSimilarity(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 	modelID := ctx.Param("modelId")
 
-	limit, ok := unireq.GetURLIntArgOrFail(ctx, "limit", 10)
-	if !ok {
-		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("invalid value of 'limit'"), http.StatusUnprocessableEntity)
+	limit, err := intQueryArg(ctx, "limit", dfltLimit)
+	if !err.IsZero() {
+		respondWithError(ctx, err)
 		return
 	}
-	minScore, ok := unireq.GetURLFloatArgOrFail(ctx, "minScore", 0)
-	if !ok {
-		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("invalid value of 'limit'"), http.StatusUnprocessableEntity)
+	minScore, err := floatQueryArg(ctx, "minScore", 0)
+	if !err.IsZero() {
+		respondWithError(ctx, err)
 		return
 	}
 	word := ctx.Param("word")
 	posOrSfn := ctx.Param("fn")
 
 	res, err := a.searcher.SimilarlyUsedWords(
-		ctx, corpusID, modelID, posOrSfn, word, limit, float32(minScore),
+		ctx.Request.Context(), corpusID, modelID, posOrSfn, word, limit, float32(minScore),
 	)
 	if !err.IsZero() {
-		uniresp.RespondWithErrorJSON(
-			ctx, err, mapError(err),
-		)
+		respondWithError(ctx, err)
 		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, res)
 }
 
+// HandleCacheStats provides statistics of the query result cache
+func (a *ActionHandler) HandleCacheStats(ctx *gin.Context) {
+	uniresp.WriteJSONResponse(ctx.Writer, a.searcher.CacheStats())
+}
+
 func (a *ActionHandler) Dictionary(ctx *gin.Context) {
 	datasetID := ctx.Param("corpusId")
 	word := ctx.Param("word")
-	ans, err := a.searcher.Dictionary(datasetID, word)
+	ans, err := a.searcher.Dictionary(ctx.Request.Context(), datasetID, ctx.Query("collDb"), word)
 	if !err.IsZero() {
-		uniresp.RespondWithErrorJSON(
-			ctx, err, mapError(err),
-		)
+		respondWithError(ctx, err)
 		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, ans)
@@ -117,10 +129,22 @@ func NewActionHandler(
 	dataDir string,
 	models queries.W2VModelProvider,
 	searcher *queries.SearchProvider,
+	deadlines config.QueryDeadlines,
+	httpCache config.HTTPCacheConf,
+	rateLimit config.RateLimitConf,
+	authn *auth.Authenticator,
 ) (*ActionHandler, error) {
 
+	var rateLimiter *RateLimiter
+	if rateLimit.Enabled {
+		rateLimiter = NewRateLimiter(rateLimit)
+	}
 	return &ActionHandler{
-		models:   models,
-		searcher: searcher,
+		models:      models,
+		searcher:    searcher,
+		deadlines:   deadlines,
+		httpCache:   httpCache,
+		authn:       authn,
+		rateLimiter: rateLimiter,
 	}, nil
 }
@@ -0,0 +1,189 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/corpora"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/openapi"
+	"github.com/czcorpus/wsserver/queries"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestAPI creates the API (as configured by wsserver) with
+// the syn2020 dataset providing a single (missing) model and
+// no collocation database
+func newTestAPI(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	dataDir := t.TempDir()
+	paths := model.NewPathResolver(dataDir, nil)
+	models := model.NewProvider(
+		paths, []model.ModelConf{{Corpname: "syn2020", ID: "m1", Filename: "m1.bin"}}, 0)
+	collDBs, err := queries.NewCollDbMap(paths, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpInfo := map[string]corpora.Info{"syn2020": {Corpname: "syn2020"}}
+	searcher, err := queries.NewSearchProvider(dataDir, collDBs, models, corpInfo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(config.AuthConf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := NewActionHandler(
+		dataDir,
+		models,
+		searcher,
+		config.QueryDeadlines{},
+		config.HTTPCacheConf{Disabled: true},
+		config.RateLimitConf{},
+		authn,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := gin.New()
+	engine.Use(RequestIDMiddleware())
+	engine.Use(ErrorMiddleware())
+	engine.NoRoute(NoRouteHandler)
+	openapi.RegisterRoutes(engine.Group(APIBasePath), handler.Routes())
+	return engine
+}
+
+func TestAPIErrors(t *testing.T) {
+	engine := newTestAPI(t)
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCode   core.ErrorType
+		wantParam  string
+	}{
+		{
+			name:       "invalid enum value",
+			target:     "/v1/dataset/syn2020/collocations/pes?sortBy=foo",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   core.ErrorTypeInvalidArguments,
+			wantParam:  "sortBy",
+		},
+		{
+			name:       "invalid integer",
+			target:     "/v1/dataset/syn2020/similarWords/m1/pes?limit=ten",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   core.ErrorTypeInvalidArguments,
+			wantParam:  "limit",
+		},
+		{
+			name:       "integer below minimum",
+			target:     "/v1/dataset/syn2020/collocations/pes?limit=0",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   core.ErrorTypeInvalidArguments,
+			wantParam:  "limit",
+		},
+		{
+			name:       "invalid number",
+			target:     "/v1/dataset/syn2020/similarWords/m1/pes?minScore=high",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   core.ErrorTypeInvalidArguments,
+			wantParam:  "minScore",
+		},
+		{
+			name:       "unknown dataset",
+			target:     "/v1/dataset/syn2015/dictionary/pes",
+			wantStatus: http.StatusNotFound,
+			wantCode:   core.ErrorTypeNotFound,
+			wantParam:  "corpusId",
+		},
+		{
+			name:       "unknown model",
+			target:     "/v1/dataset/syn2020/similarWords/m2/pes",
+			wantStatus: http.StatusNotFound,
+			wantCode:   core.ErrorTypeNotFound,
+			wantParam:  "modelId",
+		},
+		{
+			name:       "dataset without dictionary",
+			target:     "/v1/dataset/syn2020/dictionary/pes",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   core.ErrorTypeUnsupportedFeature,
+		},
+		{
+			name:       "dataset without dictionary and explicit database",
+			target:     "/v1/dataset/syn2020/dictionary/pes?collDb=syn2020",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   core.ErrorTypeUnsupportedFeature,
+		},
+		{
+			name:       "unknown endpoint",
+			target:     "/v1/foo",
+			wantStatus: http.StatusNotFound,
+			wantCode:   core.ErrorTypeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		assert.Equal(t, tt.wantStatus, w.Code, tt.name)
+		var envelope map[string]map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope), tt.name)
+		detail := envelope["error"]
+		assert.Equal(t, string(tt.wantCode), detail["code"], tt.name)
+		assert.NotEmpty(t, detail["message"], tt.name)
+		if tt.wantParam != "" {
+			assert.Equal(t, tt.wantParam, detail["param"], tt.name)
+
+		} else {
+			assert.NotContains(t, detail, "param", tt.name)
+		}
+		assert.Equal(t, w.Header().Get(RequestIDHeader), detail["requestId"], tt.name)
+		assert.NotEmpty(t, detail["requestId"], tt.name)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), tt.name)
+	}
+}
+
+func TestAPIErrorRequestID(t *testing.T) {
+	engine := newTestAPI(t)
+	tests := []struct {
+		name      string
+		requestID string
+		wantReuse bool
+	}{
+		{"client ID reused", "abc-123", true},
+		{"invalid client ID replaced", "abc 123", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/v1/dataset/syn2015/dictionary/pes", nil)
+		req.Header.Set(RequestIDHeader, tt.requestID)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		var envelope ErrorEnvelope
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope), tt.name)
+		assert.Equal(t, tt.wantReuse, envelope.Error.RequestID == tt.requestID, tt.name)
+		assert.Equal(t, w.Header().Get(RequestIDHeader), envelope.Error.RequestID, tt.name)
+	}
+}
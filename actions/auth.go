@@ -0,0 +1,100 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// AuthMiddleware authenticates clients by their API keys and attaches
+// respective principals to request contexts. Requests with an invalid
+// key are rejected. Requests without a key are processed as anonymous
+// (i.e. with access to public datasets only).
+func AuthMiddleware(authn *auth.Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, appErr := authn.Authenticate(auth.KeyFromRequest(ctx.Request))
+		if !appErr.IsZero() {
+			log.Warn().
+				Str("clientAddress", getClientAddress(ctx.Request)).
+				Str("path", ctx.Request.URL.Path).
+				Msg("rejected request with an invalid API key")
+			ctx.Header("WWW-Authenticate", "Bearer")
+			respondWithError(ctx, appErr)
+			return
+		}
+		if principal != nil {
+			ctx.Request = ctx.Request.WithContext(
+				auth.WithPrincipal(ctx.Request.Context(), principal))
+		}
+		ctx.Next()
+	}
+}
+
+// withAccessControl rejects requests for datasets and models
+// the client is not allowed to access
+func withAccessControl(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		appErr := auth.CheckAccess(ctx.Request.Context(), ctx.Param("corpusId"), ctx.Param("modelId"))
+		if !appErr.IsZero() {
+			respondWithError(ctx, appErr)
+			return
+		}
+		handler(ctx)
+	}
+}
+
+// withServiceAccessControl rejects requests of clients
+// without access to service endpoints
+func withServiceAccessControl(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if appErr := auth.CheckServiceAccess(ctx.Request.Context()); !appErr.IsZero() {
+			respondWithError(ctx, appErr)
+			return
+		}
+		handler(ctx)
+	}
+}
+
+// applyAccessControl wraps handlers of all the dataset-specific
+// routes by withAccessControl and handlers of service routes
+// (tagged with serviceTag) by withServiceAccessControl
+func (a *ActionHandler) applyAccessControl(routes []openapi.Route) []openapi.Route {
+	if !a.authn.Enabled() {
+		return routes
+	}
+	for i, route := range routes {
+		if slices.Contains(route.Operation.Tags, serviceTag) {
+			routes[i].Handler = withServiceAccessControl(route.Handler)
+			routes[i].Operation.Responses["401"] = errResponse("missing or invalid API key")
+			routes[i].Operation.Responses["403"] = errResponse("the API key does not grant admin access")
+			continue
+		}
+		if !strings.Contains(route.Path, ":corpusId") {
+			continue
+		}
+		routes[i].Handler = withAccessControl(route.Handler)
+		routes[i].Operation.Responses["401"] = errResponse("missing or invalid API key")
+		routes[i].Operation.Responses["403"] = errResponse("access to the dataset or model denied")
+	}
+	return routes
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/gin-gonic/gin"
+)
+
+// unixSocketClient identifies clients connected via the Unix socket
+// whose address cannot be resolved
+const unixSocketClient = "unix"
+
+// ClientAddrResolver finds out the address of a client even if
+// the request passed through reverse proxies. The forwarding header
+// is considered only if sent by a trusted proxy.
+type ClientAddrResolver struct {
+	trustedProxies []netip.Prefix
+
+	// useForwarded means trusted proxies set the Forwarded header
+	// instead of X-Forwarded-For
+	useForwarded bool
+}
+
+func (r *ClientAddrResolver) isTrusted(addr netip.Addr) bool {
+	return slices.ContainsFunc(r.trustedProxies, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// parseNode parses a node of a forwarding header. Both IPv4 and
+// IPv6 addresses are accepted with an optional port (IPv6 with
+// a port must be in brackets). Quotes (used by the Forwarded header)
+// are removed.
+func parseNode(v string) (netip.Addr, bool) {
+	v = strings.Trim(strings.TrimSpace(v), `"`)
+	if addrPort, err := netip.ParseAddrPort(v); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(v, "["), "]"))
+	if err != nil {
+		// e.g. "unknown" or an obfuscated identifier
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// forwardedFor returns "for" nodes of the standard Forwarded
+// header (RFC 7239) in the order they were added by proxies
+func forwardedFor(values []string) []string {
+	ans := make([]string, 0, 4)
+	for _, value := range values {
+		for _, elm := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(elm, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					ans = append(ans, val)
+				}
+			}
+		}
+	}
+	return ans
+}
+
+// xForwardedFor returns nodes of the X-Forwarded-For header
+func xForwardedFor(values []string) []string {
+	ans := make([]string, 0, 4)
+	for _, value := range values {
+		ans = append(ans, strings.Split(value, ",")...)
+	}
+	return ans
+}
+
+// isUnixSocketPeer tells whether a request came via a Unix domain socket
+func isUnixSocketPeer(req *http.Request) bool {
+	_, ok := req.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr)
+	return ok
+}
+
+// Resolve returns the client address. The forwarding chain (of the
+// header set by trusted proxies, the other one is ignored) is walked from
+// the nearest node as long as the nodes are trusted proxies. The
+// first untrusted node is the client. Peers connected via a Unix
+// socket (typically a reverse proxy on the same host) are always
+// trusted. In case the client cannot be determined (an unparseable
+// node like "unknown", or a socket peer with no forwarding headers),
+// an invalid address is returned.
+func (r *ClientAddrResolver) Resolve(req *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, ok := parseNode(host)
+	if ok && !r.isTrusted(addr) {
+		return addr
+	}
+	if !ok && !isUnixSocketPeer(req) {
+		return netip.Addr{}
+	}
+	var chain []string
+	if r.useForwarded {
+		chain = forwardedFor(req.Header.Values("Forwarded"))
+
+	} else {
+		chain = xForwardedFor(req.Header.Values("X-Forwarded-For"))
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok = parseNode(chain[i])
+		if !ok {
+			return netip.Addr{}
+		}
+		if !r.isTrusted(addr) {
+			return addr
+		}
+	}
+	// all the nodes are trusted proxies so the farthest one is the best guess
+	return addr
+}
+
+// NewClientAddrResolver is a recommended factory function for ClientAddrResolver.
+// The forwardedHeader is one of config.ForwardedHeaderXFF (also used
+// for an empty value) and config.ForwardedHeaderForwarded.
+func NewClientAddrResolver(trustedProxies []netip.Prefix, forwardedHeader string) *ClientAddrResolver {
+	return &ClientAddrResolver{
+		trustedProxies: trustedProxies,
+		useForwarded:   forwardedHeader == config.ForwardedHeaderForwarded,
+	}
+}
+
+// ClientAddressMiddleware replaces the remote address of each request
+// by the resolved client address so all the request processing (logging,
+// rate limiting, access control, tracing) works with the same address.
+// Forwarding headers are normalized too as some components (e.g. tracing
+// instrumentation) read X-Forwarded-For directly. In case the client cannot
+// be resolved, the request is left untouched. The gin engine should not
+// resolve forwarding headers on its own (see gin.Engine.ForwardedByClientIP).
+func ClientAddressMiddleware(resolver *ClientAddrResolver) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if addr := resolver.Resolve(ctx.Request); addr.IsValid() {
+			ctx.Request.Header.Del("Forwarded")
+			ctx.Request.Header.Set("X-Forwarded-For", addr.String())
+			_, port, err := net.SplitHostPort(ctx.Request.RemoteAddr)
+			if err != nil {
+				port = "0"
+			}
+			ctx.Request.RemoteAddr = net.JoinHostPort(addr.String(), port)
+		}
+		ctx.Next()
+	}
+}
+
+// getClientAddress returns the client address of a request
+// processed by ClientAddressMiddleware. Unix socket peers which
+// have not been resolved (no forwarding headers) share the
+// unixSocketClient identifier.
+func getClientAddress(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	if _, ok := parseNode(host); !ok && isUnixSocketPeer(req) {
+		return unixSocketClient
+	}
+	return host
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNode(t *testing.T) {
+	tests := []struct {
+		node  string
+		want  string
+		valid bool
+	}{
+		{"192.0.2.1", "192.0.2.1", true},
+		{" 192.0.2.1:4711 ", "192.0.2.1", true},
+		{"2001:db8::1", "2001:db8::1", true},
+		{"[2001:db8::1]", "2001:db8::1", true},
+		{`"[2001:db8::1]:4711"`, "2001:db8::1", true},
+		{"::ffff:192.0.2.1", "192.0.2.1", true},
+		{"unknown", "", false},
+		{"_hidden", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		addr, ok := parseNode(tt.node)
+		assert.Equal(t, tt.valid, ok, tt.node)
+		if tt.valid {
+			assert.Equal(t, tt.want, addr.String(), tt.node)
+		}
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	nodes := forwardedFor([]string{
+		`for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8::1]:4711"`,
+		"proto=https;for=unknown",
+	})
+	assert.Equal(t, []string{"192.0.2.60", `"[2001:db8::1]:4711"`, "unknown"}, nodes)
+}
+
+func TestClientAddrResolverResolve(t *testing.T) {
+	trustedProxies := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+	tests := []struct {
+		name            string
+		remoteAddr      string
+		unixSocket      bool
+		forwardedHeader string
+		headers         map[string]string
+		want            string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "198.51.100.7:5000",
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "spoofed header from untrusted peer",
+			remoteAddr: "198.51.100.7:5000",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.1"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "single trusted proxy",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.1"},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "chain of trusted proxies with a spoofed prefix",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9, 192.0.2.1, 10.0.0.2"},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "all nodes trusted",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.1:5000",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "unparseable client node",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Forwarded-For": "unknown, 10.0.0.2"},
+			want:       "invalid IP",
+		},
+		{
+			name:            "forwarded header of proxies",
+			remoteAddr:      "[fd00::1]:5000",
+			forwardedHeader: config.ForwardedHeaderForwarded,
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8::7]:4711", for=10.0.0.2`,
+				"X-Forwarded-For": "192.0.2.1",
+			},
+			want: "2001:db8::7",
+		},
+		{
+			name:       "forged forwarded header with proxy-appended XFF",
+			remoteAddr: "10.0.0.1:5000",
+			headers: map[string]string{
+				"Forwarded":       "for=1.2.3.4",
+				"X-Forwarded-For": "192.0.2.1",
+			},
+			want: "192.0.2.1",
+		},
+		{
+			name:            "forged XFF with proxy-set forwarded header",
+			remoteAddr:      "10.0.0.1:5000",
+			forwardedHeader: config.ForwardedHeaderForwarded,
+			headers: map[string]string{
+				"Forwarded":       "for=192.0.2.1",
+				"X-Forwarded-For": "1.2.3.4",
+			},
+			want: "192.0.2.1",
+		},
+		{
+			name:            "no fallback to XFF",
+			remoteAddr:      "10.0.0.1:5000",
+			forwardedHeader: config.ForwardedHeaderForwarded,
+			headers:         map[string]string{"X-Forwarded-For": "192.0.2.1"},
+			want:            "10.0.0.1",
+		},
+		{
+			name:            "no fallback to forwarded header",
+			remoteAddr:      "10.0.0.1:5000",
+			forwardedHeader: config.ForwardedHeaderXFF,
+			headers:         map[string]string{"Forwarded": "for=192.0.2.1"},
+			want:            "10.0.0.1",
+		},
+		{
+			name:       "unix socket peer is trusted",
+			remoteAddr: "@",
+			unixSocket: true,
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.1"},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "unix socket peer without headers",
+			remoteAddr: "",
+			unixSocket: true,
+			want:       "invalid IP",
+		},
+		{
+			name:       "non-IP peer of a TCP listener",
+			remoteAddr: "@",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.1"},
+			want:       "invalid IP",
+		},
+	}
+	for _, tt := range tests {
+		resolver := NewClientAddrResolver(trustedProxies, tt.forwardedHeader)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.unixSocket {
+			req = req.WithContext(context.WithValue(
+				req.Context(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/wss.sock", Net: "unix"}))
+		}
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		assert.Equal(t, tt.want, resolver.Resolve(req).String(), tt.name)
+	}
+}
+
+func TestClientAddressMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resolver := NewClientAddrResolver([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, config.ForwardedHeaderXFF)
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		xForwardedFor  string
+		wantRemoteAddr string
+		wantXFF        string
+	}{
+		{
+			name:           "resolved address replaces headers",
+			remoteAddr:     "10.0.0.1:5000",
+			xForwardedFor:  "203.0.113.9, 192.0.2.1",
+			wantRemoteAddr: "192.0.2.1:5000",
+			wantXFF:        "192.0.2.1",
+		},
+		{
+			name:           "unresolved request is left untouched",
+			remoteAddr:     "10.0.0.1:5000",
+			xForwardedFor:  "unknown",
+			wantRemoteAddr: "10.0.0.1:5000",
+			wantXFF:        "unknown",
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx.Request.RemoteAddr = tt.remoteAddr
+		ctx.Request.Header.Set("X-Forwarded-For", tt.xForwardedFor)
+		ClientAddressMiddleware(resolver)(ctx)
+		assert.Equal(t, tt.wantRemoteAddr, ctx.Request.RemoteAddr, tt.name)
+		assert.Equal(t, tt.wantXFF, ctx.Request.Header.Get("X-Forwarded-For"), tt.name)
+	}
+}
+
+func TestGetClientAddress(t *testing.T) {
+	unixCtx := context.WithValue(
+		context.Background(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/wss.sock", Net: "unix"})
+	tests := []struct {
+		name       string
+		remoteAddr string
+		ctx        context.Context
+		want       string
+	}{
+		{"TCP client", "192.0.2.1:5000", context.Background(), "192.0.2.1"},
+		{"IPv6 TCP client", "[2001:db8::1]:5000", context.Background(), "2001:db8::1"},
+		{"resolved socket client", "192.0.2.1:0", unixCtx, "192.0.2.1"},
+		{"unresolved socket client", "@", unixCtx, unixSocketClient},
+		{"unnamed socket client", "", unixCtx, unixSocketClient},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tt.ctx)
+		req.RemoteAddr = tt.remoteAddr
+		assert.Equal(t, tt.want, getClientAddress(req), tt.name)
+	}
+}
@@ -1,12 +1,10 @@
 package actions
 
 import (
-	"fmt"
-	"net/http"
-
-	"github.com/czcorpus/cnc-gokit/unireq"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/czcorpus/wsserver/core"
 	"github.com/czcorpus/wsserver/queries"
 	"github.com/gin-gonic/gin"
 )
@@ -25,12 +23,25 @@ type lemmaCollocates struct {
 
 type collGroupedResponse struct {
 	Matches []lemmaCollocates `json:"matches"`
-	Error   error             `json:"error,omitempty"`
 }
 
 type collResponse struct {
 	Items []queries.SimpleCollocation `json:"items"`
-	Error error                       `json:"error,omitempty"`
+}
+
+// collQueryArgs reads limit and sorting arguments
+// shared by the collocation actions
+func collQueryArgs(ctx *gin.Context) (int, storage.SortingMeasure, core.AppError) {
+	limit, err := intQueryArg(ctx, "limit", dfltLimit)
+	if !err.IsZero() {
+		return 0, "", err
+	}
+	sortBy := storage.SortingMeasure(ctx.DefaultQuery("sortBy", "rrf"))
+	if !sortBy.Validate() {
+		return 0, "", core.NewAppError(
+			"invalid value of 'sortBy'", core.ErrorTypeInvalidArguments, nil).WithParam("sortBy")
+	}
+	return limit, sortBy, core.AppError{}
 }
 
 func (a *ActionHandler) Collocations(ctx *gin.Context) {
@@ -39,24 +50,26 @@ func (a *ActionHandler) Collocations(ctx *gin.Context) {
 	word := ctx.Param("word")
 	pos := ctx.Param("pos")
 	tt := ctx.Query("tt")
+	collDBID := ctx.Query("collDb")
 
-	limit, ok := unireq.GetURLIntArgOrFail(ctx, "limit", 10)
-	if !ok {
-		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("invalid value of 'limit'"), http.StatusUnprocessableEntity)
+	limit, sortBy, err := collQueryArgs(ctx)
+	if !err.IsZero() {
+		respondWithError(ctx, err)
 		return
 	}
 
 	result, err := a.searcher.Collocations(
-		ctx,
+		ctx.Request.Context(),
 		corpusID,
+		collDBID,
 		word,
 		scoll.WithPoS(pos),
 		scoll.WithLimit(limit),
-		scoll.WithSortBy("rrf"),
+		scoll.WithSortBy(sortBy),
 		scoll.WithTextType(tt),
 	)
 	if !err.IsZero() {
-		uniresp.RespondWithErrorJSON(ctx, err, mapError(err))
+		respondWithError(ctx, err)
 		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, collResponse{Items: result})
@@ -67,26 +80,30 @@ func (a *ActionHandler) CollocationsOfType(ctx *gin.Context) {
 	corpusID := ctx.Param("corpusId")
 	word := ctx.Param("word")
 	collType := ctx.Param("type")
+	pos := ctx.Param("pos")
 	tt := ctx.Query("tt")
+	collDBID := ctx.Query("collDb")
 
-	limit, ok := unireq.GetURLIntArgOrFail(ctx, "limit", 10)
-	if !ok {
-		uniresp.RespondWithErrorJSON(ctx, fmt.Errorf("invalid value of 'limit'"), http.StatusUnprocessableEntity)
+	limit, sortBy, err := collQueryArgs(ctx)
+	if !err.IsZero() {
+		respondWithError(ctx, err)
 		return
 	}
 
 	result, err := a.searcher.Collocations(
-		ctx,
+		ctx.Request.Context(),
 		corpusID,
+		collDBID,
 		word,
+		scoll.WithPoS(pos),
 		scoll.WithLimit(limit),
-		scoll.WithSortBy("rrf"),
+		scoll.WithSortBy(sortBy),
 		scoll.WithTextType(tt),
 		scoll.WithPredefinedSearch(scoll.PredefinedSearch(collType)),
 		scoll.WithMaxAvgCollocateDist(1.499),
 	)
 	if !err.IsZero() {
-		uniresp.RespondWithErrorJSON(ctx, err, mapError(err))
+		respondWithError(ctx, err)
 		return
 	}
 	uniresp.WriteJSONResponse(ctx.Writer, collResponse{Items: result})
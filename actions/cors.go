@@ -0,0 +1,149 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/gin-gonic/gin"
+)
+
+// subdomainOrigin matches origins of all the subdomains of a host
+type subdomainOrigin struct {
+	scheme string
+	suffix string
+}
+
+func (so subdomainOrigin) matches(origin string) bool {
+	return strings.HasPrefix(origin, so.scheme) && strings.HasSuffix(origin, so.suffix) &&
+		len(origin) > len(so.scheme)+len(so.suffix)
+}
+
+// corsPolicy holds a preprocessed CORS configuration
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          []string
+	subdomainOrigins []subdomainOrigin
+	allowCredentials bool
+	methods          string
+	headers          string
+	exposedHeaders   string
+	maxAge           string
+	allowedMethods   []string
+}
+
+// isWildcard tells whether the policy allows any origin using
+// "Access-Control-Allow-Origin: *", i.e. responses are the same
+// for all the origins
+func (p *corsPolicy) isWildcard() bool {
+	return p.anyOrigin && !p.allowCredentials
+}
+
+// originAllowed tests an Origin header value against the configured
+// origins. Origins are compared case-insensitively.
+func (p *corsPolicy) originAllowed(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	return slices.Contains(p.origins, origin) ||
+		slices.ContainsFunc(p.subdomainOrigins, func(so subdomainOrigin) bool { return so.matches(origin) })
+}
+
+func newCORSPolicy(conf config.CORSConf) *corsPolicy {
+	ans := &corsPolicy{
+		allowCredentials: conf.AllowCredentials,
+		methods:          strings.Join(conf.AllowedMethods, ", "),
+		headers:          strings.Join(conf.AllowedHeaders, ", "),
+		exposedHeaders:   strings.Join(conf.ExposedHeaders, ", "),
+		maxAge:           strconv.Itoa(conf.MaxAgeSecs),
+		allowedMethods:   make([]string, len(conf.AllowedMethods)),
+	}
+	for i, m := range conf.AllowedMethods {
+		ans.allowedMethods[i] = strings.ToUpper(m)
+	}
+	for _, origin := range conf.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		if origin == config.CORSAnyOrigin {
+			ans.anyOrigin = true
+
+		} else if scheme, host, ok := strings.Cut(origin, "://*."); ok {
+			ans.subdomainOrigins = append(ans.subdomainOrigins, subdomainOrigin{scheme: scheme + "://", suffix: "." + host})
+
+		} else {
+			ans.origins = append(ans.origins, origin)
+		}
+	}
+	return ans
+}
+
+// CORSMiddleware adds CORS headers to responses for allowed origins
+// (including error responses so browser scripts can read them) and
+// answers preflight requests. Requests from other origins are processed
+// without CORS headers, i.e. browsers will not expose the responses.
+// Unless the policy is a wildcard one (the same headers for everyone),
+// responses vary on Origin so shared caches keep them apart.
+func CORSMiddleware(conf config.CORSConf) gin.HandlerFunc {
+	policy := newCORSPolicy(conf)
+	return func(ctx *gin.Context) {
+		if !policy.isWildcard() {
+			ctx.Writer.Header().Add("Vary", "Origin")
+		}
+		origin := ctx.GetHeader("Origin")
+		if origin == "" && policy.isWildcard() {
+			ctx.Header("Access-Control-Allow-Origin", "*")
+			if policy.exposedHeaders != "" {
+				ctx.Header("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
+			ctx.Next()
+			return
+		}
+		if origin == "" || !policy.originAllowed(origin) {
+			ctx.Next()
+			return
+		}
+		if policy.isWildcard() {
+			ctx.Header("Access-Control-Allow-Origin", "*")
+
+		} else {
+			ctx.Header("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			ctx.Header("Access-Control-Allow-Credentials", "true")
+		}
+		reqMethod := ctx.GetHeader("Access-Control-Request-Method")
+		if ctx.Request.Method == http.MethodOptions && reqMethod != "" {
+			ctx.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			ctx.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			if slices.Contains(policy.allowedMethods, strings.ToUpper(reqMethod)) {
+				ctx.Header("Access-Control-Allow-Methods", policy.methods)
+				ctx.Header("Access-Control-Allow-Headers", policy.headers)
+				ctx.Header("Access-Control-Max-Age", policy.maxAge)
+			}
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if policy.exposedHeaders != "" {
+			ctx.Header("Access-Control-Expose-Headers", policy.exposedHeaders)
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSPolicyOriginAllowed(t *testing.T) {
+	policy := newCORSPolicy(config.CORSConf{
+		AllowedOrigins: []string{"https://app.example.org/", "https://*.korpus.cz"},
+	})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.org", true},
+		{"HTTPS://App.Example.org", true},
+		{"http://app.example.org", false},
+		{"https://app.example.org.evil.com", false},
+		{"https://www.korpus.cz", true},
+		{"https://a.b.korpus.cz", true},
+		{"https://korpus.cz", false},
+		{"https://.korpus.cz", false},
+		{"http://www.korpus.cz", false},
+		{"https://evilkorpus.cz", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.originAllowed(tt.origin), tt.origin)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	specific := config.CORSConf{
+		AllowedOrigins: []string{"https://app.example.org"},
+		AllowedMethods: []string{"GET"},
+		AllowedHeaders: []string{"X-API-Key"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAgeSecs:     600,
+	}
+	wildcard := config.CORSConf{
+		AllowedOrigins: []string{config.CORSAnyOrigin},
+		AllowedMethods: []string{"GET"},
+		ExposedHeaders: []string{"ETag"},
+	}
+	credentials := config.CORSConf{
+		AllowedOrigins:   []string{config.CORSAnyOrigin},
+		AllowedMethods:   []string{"GET"},
+		AllowCredentials: true,
+	}
+	tests := []struct {
+		name        string
+		conf        config.CORSConf
+		method      string
+		headers     map[string]string
+		wantStatus  int
+		wantOrigin  string
+		wantVary    []string
+		wantMethods string
+	}{
+		{
+			name:       "allowed origin",
+			conf:       specific,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://app.example.org"},
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.org",
+			wantVary:   []string{"Origin"},
+		},
+		{
+			name:       "no origin still varies",
+			conf:       specific,
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantVary:   []string{"Origin"},
+		},
+		{
+			name:       "disallowed origin",
+			conf:       specific,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://evil.example.com"},
+			wantStatus: http.StatusOK,
+			wantVary:   []string{"Origin"},
+		},
+		{
+			name:   "preflight",
+			conf:   specific,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.org",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://app.example.org",
+			wantVary:    []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			wantMethods: "GET",
+		},
+		{
+			name:   "preflight of a disallowed method",
+			conf:   specific,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.org",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			wantStatus: http.StatusNoContent,
+			wantOrigin: "https://app.example.org",
+			wantVary:   []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:       "wildcard with origin",
+			conf:       wildcard,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://any.example.com"},
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name:       "wildcard without origin",
+			conf:       wildcard,
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name:       "any origin with credentials echoes the origin",
+			conf:       credentials,
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://any.example.com"},
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://any.example.com",
+			wantVary:   []string{"Origin"},
+		},
+	}
+	for _, tt := range tests {
+		engine := gin.New()
+		engine.Use(CORSMiddleware(tt.conf))
+		engine.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		req := httptest.NewRequest(tt.method, "/", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tt.wantStatus, w.Code, tt.name)
+		assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"), tt.name)
+		assert.Equal(t, tt.wantVary, w.Header().Values("Vary"), tt.name)
+		assert.Equal(t, tt.wantMethods, w.Header().Get("Access-Control-Allow-Methods"), tt.name)
+	}
+}
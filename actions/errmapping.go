@@ -7,6 +7,14 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// modelLoadingRetryAfterSecs is a suggested delay before a client
+// repeats a request which failed due to a model being loaded
+const modelLoadingRetryAfterSecs = 10
+
+// statusClientClosedRequest is a non-standard status (introduced by nginx)
+// used for requests cancelled by their clients
+const statusClientClosedRequest = 499
+
 func mapError(err core.AppError) int {
 	switch err.Type {
 	case core.ErrorTypeInternalError:
@@ -14,7 +22,24 @@ func mapError(err core.AppError) int {
 	case core.ErrorTypeNotFound:
 		return http.StatusNotFound
 	case core.ErrorTypeInvalidArguments:
+		// kept for compatibility with clients of the legacy API
 		return http.StatusBadRequest
+	case core.ErrorTypeUnsupportedFeature:
+		return http.StatusUnprocessableEntity
+	case core.ErrorTypeModelLoading:
+		return http.StatusServiceUnavailable
+	case core.ErrorTypeTimeout:
+		return http.StatusGatewayTimeout
+	case core.ErrorTypeCancelled:
+		return statusClientClosedRequest
+	case core.ErrorTypeRateLimited:
+		return http.StatusTooManyRequests
+	case core.ErrorTypeUnauthorized:
+		return http.StatusUnauthorized
+	case core.ErrorTypeForbidden:
+		return http.StatusForbidden
+	case core.ErrorTypeUnavailable:
+		return http.StatusServiceUnavailable
 	default:
 		log.Warn().Str("errType", string(err.Type)).Msg("encountered an unknown error type")
 		return http.StatusInternalServerError
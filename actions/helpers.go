@@ -17,14 +17,37 @@
 package actions
 
 import (
-	"net/http"
-	"strings"
+	"fmt"
+	"strconv"
+
+	"github.com/czcorpus/wsserver/core"
+	"github.com/gin-gonic/gin"
 )
 
-func getClientAddress(req *http.Request) string {
-	ans := req.Header.Get("X-Forwarded-For")
-	if ans == "" {
-		ans = req.RemoteAddr
+// intQueryArg reads an optional integer URL query argument
+func intQueryArg(ctx *gin.Context, name string, dflt int) (int, core.AppError) {
+	v, ok := ctx.GetQuery(name)
+	if !ok {
+		return dflt, core.AppError{}
+	}
+	ans, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, core.NewAppError(
+			fmt.Sprintf("invalid value of '%s'", name), core.ErrorTypeInvalidArguments, err).WithParam(name)
+	}
+	return ans, core.AppError{}
+}
+
+// floatQueryArg reads an optional float URL query argument
+func floatQueryArg(ctx *gin.Context, name string, dflt float64) (float64, core.AppError) {
+	v, ok := ctx.GetQuery(name)
+	if !ok {
+		return dflt, core.AppError{}
+	}
+	ans, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, core.NewAppError(
+			fmt.Sprintf("invalid value of '%s'", name), core.ErrorTypeInvalidArguments, err).WithParam(name)
 	}
-	return strings.Split(ans, ":")[0]
+	return ans, core.AppError{}
 }
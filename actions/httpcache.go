@@ -0,0 +1,141 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// privateCacheControl is used instead of the configured Cache-Control
+// for authenticated clients so shared caches do not store responses
+// with restricted data
+const privateCacheControl = "private, no-cache"
+
+// dataVersionFunc provides a version of data a request is answered
+// from. An empty string means the version is unknown and no cache
+// validators are sent.
+type dataVersionFunc func(ctx *gin.Context) string
+
+func (a *ActionHandler) modelVersion(ctx *gin.Context) string {
+	return a.searcher.ModelVersion(ctx.Param("corpusId"), ctx.Param("modelId"))
+}
+
+func (a *ActionHandler) collDBVersion(ctx *gin.Context) string {
+	return a.searcher.CollDBVersion(ctx.Param("corpusId"), ctx.Query("collDb"))
+}
+
+// mkETag creates a strong ETag from a data version and all
+// the request arguments (path and query)
+func mkETag(version string, req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(version))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.Query().Encode()))
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches tests an If-None-Match header value against an ETag.
+// As required for If-None-Match, weak comparison is used.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, item := range strings.Split(ifNoneMatch, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.TrimPrefix(item, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// withHTTPCaching adds ETag and Cache-Control headers to responses
+// and answers matching conditional requests with 304 Not Modified
+// without running the handler.
+func withHTTPCaching(cacheControl string, versionFn dataVersionFunc, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		version := versionFn(ctx)
+		if version == "" {
+			handler(ctx)
+			return
+		}
+		etag := mkETag(version, ctx.Request)
+		ctx.Header("ETag", etag)
+		respCacheControl := cacheControl
+		if principal := auth.FromContext(ctx.Request.Context()); principal != nil {
+			ctx.Writer.Header().Add("Vary", "Authorization, "+auth.APIKeyHeader)
+			if !principal.Anonymous {
+				respCacheControl = privateCacheControl
+			}
+		}
+		if respCacheControl != "" {
+			ctx.Header("Cache-Control", respCacheControl)
+		}
+		if inm := ctx.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			ctx.Status(http.StatusNotModified)
+			ctx.Abort()
+			return
+		}
+		handler(ctx)
+	}
+}
+
+// applyHTTPCaching wraps handlers of routes answered from versioned
+// data (models, collocation databases) by withHTTPCaching
+func (a *ActionHandler) applyHTTPCaching(routes []openapi.Route) []openapi.Route {
+	if a.httpCache.Disabled {
+		return routes
+	}
+	versions := map[string]dataVersionFunc{
+		"dictionary":                a.collDBVersion,
+		"collocations":              a.collDBVersion,
+		"collocationsWithPoS":       a.collDBVersion,
+		"collocationsOfType":        a.collDBVersion,
+		"collocationsOfTypeWithPoS": a.collDBVersion,
+		"similarWords":              a.modelVersion,
+		"similarWordsWithFn":        a.modelVersion,
+	}
+	for opID := range a.httpCache.RouteCacheControl {
+		if _, ok := versions[opID]; !ok {
+			log.Warn().
+				Str("operationId", opID).
+				Msg("httpCache.routeCacheControl refers to an operation without HTTP caching, ignoring")
+		}
+	}
+	for i, route := range routes {
+		versionFn, ok := versions[route.Operation.OperationID]
+		if !ok {
+			continue
+		}
+		cacheControl := a.httpCache.CacheControl
+		if v, ok := a.httpCache.RouteCacheControl[route.Operation.OperationID]; ok {
+			cacheControl = v
+		}
+		routes[i].Handler = withHTTPCaching(cacheControl, versionFn, route.Handler)
+		routes[i].Operation.Responses["304"] = openapi.Response{
+			Description: "not modified (the If-None-Match header matches the current ETag)",
+		}
+	}
+	return routes
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMkETag(t *testing.T) {
+	mkReq := func(target string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, target, nil)
+	}
+	etag := mkETag("v1", mkReq("/dataset/syn2020/dictionary/pes?limit=10&collDb=a"))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(
+		t, etag, mkETag("v1", mkReq("/dataset/syn2020/dictionary/pes?collDb=a&limit=10")),
+		"query argument order does not matter")
+	assert.NotEqual(t, etag, mkETag("v2", mkReq("/dataset/syn2020/dictionary/pes?limit=10&collDb=a")))
+	assert.NotEqual(t, etag, mkETag("v1", mkReq("/dataset/syn2020/dictionary/pes?limit=20&collDb=a")))
+	assert.NotEqual(t, etag, mkETag("v1", mkReq("/dataset/syn2020/dictionary/kocka?limit=10&collDb=a")))
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`*`, true},
+		{`"xyz", "abc"`, true},
+		{`"xyz",W/"abc"`, true},
+		{` "abc" `, true},
+		{`"xyz"`, false},
+		{`abc`, false},
+		{`"abcd"`, false},
+		{`w/"abc"`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, etagMatches(tt.ifNoneMatch, etag), tt.ifNoneMatch)
+	}
+}
+
+func TestWithHTTPCaching(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const target = "/dataset/syn2020/similarWords/m1/pes"
+	currETag := mkETag("v1", httptest.NewRequest(http.MethodGet, target, nil))
+	tests := []struct {
+		name             string
+		version          string
+		ifNoneMatch      string
+		principal        *auth.Principal
+		wantStatus       int
+		wantETag         string
+		wantCacheControl string
+		wantVary         string
+	}{
+		{
+			name:             "fresh request",
+			version:          "v1",
+			wantStatus:       http.StatusOK,
+			wantETag:         currETag,
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:             "matching conditional request",
+			version:          "v1",
+			ifNoneMatch:      currETag,
+			wantStatus:       http.StatusNotModified,
+			wantETag:         currETag,
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:             "stale conditional request",
+			version:          "v2",
+			ifNoneMatch:      currETag,
+			wantStatus:       http.StatusOK,
+			wantETag:         mkETag("v2", httptest.NewRequest(http.MethodGet, target, nil)),
+			wantCacheControl: "public, max-age=60",
+		},
+		{
+			name:        "unknown version",
+			version:     "",
+			ifNoneMatch: currETag,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:             "anonymous client",
+			version:          "v1",
+			principal:        &auth.Principal{Anonymous: true},
+			wantStatus:       http.StatusOK,
+			wantETag:         currETag,
+			wantCacheControl: "public, max-age=60",
+			wantVary:         "Authorization, " + auth.APIKeyHeader,
+		},
+		{
+			name:             "authenticated client",
+			version:          "v1",
+			principal:        &auth.Principal{Name: "user"},
+			wantStatus:       http.StatusOK,
+			wantETag:         currETag,
+			wantCacheControl: privateCacheControl,
+			wantVary:         "Authorization, " + auth.APIKeyHeader,
+		},
+	}
+	for _, tt := range tests {
+		var handlerCalls int
+		handler := withHTTPCaching(
+			"public, max-age=60",
+			func(ctx *gin.Context) string { return tt.version },
+			func(ctx *gin.Context) {
+				handlerCalls++
+				ctx.Status(http.StatusOK)
+			},
+		)
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		if tt.principal != nil {
+			ctx.Request = ctx.Request.WithContext(auth.WithPrincipal(ctx.Request.Context(), tt.principal))
+		}
+		if tt.ifNoneMatch != "" {
+			ctx.Request.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		handler(ctx)
+		ctx.Writer.WriteHeaderNow()
+		assert.Equal(t, tt.wantStatus, w.Code, tt.name)
+		assert.Equal(t, tt.wantETag, w.Header().Get("ETag"), tt.name)
+		assert.Equal(t, tt.wantCacheControl, w.Header().Get("Cache-Control"), tt.name)
+		assert.Equal(t, tt.wantVary, w.Header().Get("Vary"), tt.name)
+		if tt.wantStatus == http.StatusNotModified {
+			assert.Equal(t, 0, handlerCalls, tt.name)
+		} else {
+			assert.Equal(t, 1, handlerCalls, tt.name)
+		}
+	}
+}
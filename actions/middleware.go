@@ -0,0 +1,149 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/czcorpus/cnc-gokit/uniresp"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDCtxKey = "requestId"
+)
+
+var validRequestID = regexp.MustCompile(`^[\w\-.]{1,64}$`)
+
+// ErrorDetail is a machine-readable description of an API error
+type ErrorDetail struct {
+	Code      core.ErrorType `json:"code"`
+	Message   string         `json:"message"`
+	Param     string         `json:"param,omitempty"`
+	RequestID string         `json:"requestId,omitempty"`
+}
+
+// ErrorEnvelope is a body of all the API error responses
+type ErrorEnvelope struct {
+	Error ErrorDetail `json:"error"`
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		log.Error().Err(err).Msg("failed to generate request ID")
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// RequestIDMiddleware attaches an ID to each request. A sane ID provided
+// by the client (or a proxy) via the X-Request-ID header is reused.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(reqID) {
+			reqID = newRequestID()
+		}
+		ctx.Set(requestIDCtxKey, reqID)
+		ctx.Header(RequestIDHeader, reqID)
+		ctx.Next()
+	}
+}
+
+// RequestID returns ID of the current request (if any)
+func RequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDCtxKey)
+}
+
+// withDeadline sets a deadline for the request's context
+func withDeadline(timeoutSecs int, handler gin.HandlerFunc) gin.HandlerFunc {
+	if timeoutSecs <= 0 {
+		return handler
+	}
+	return func(ctx *gin.Context) {
+		reqCtx, cancel := context.WithTimeout(
+			ctx.Request.Context(), time.Duration(timeoutSecs)*time.Second)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		handler(ctx)
+	}
+}
+
+// respondWithError registers an error to be written
+// by ErrorMiddleware and stops request processing
+func respondWithError(ctx *gin.Context, err core.AppError) {
+	ctx.Error(err)
+	ctx.Abort()
+}
+
+// ErrorMiddleware writes the last error registered via ctx.Error as
+// an ErrorEnvelope. Errors other than core.AppError are reported
+// as internal errors.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		var appErr core.AppError
+		if !errors.As(ctx.Errors.Last().Err, &appErr) {
+			appErr = core.NewAppError(
+				ctx.Errors.Last().Error(), core.ErrorTypeInternalError, ctx.Errors.Last().Err)
+		}
+		status := mapError(appErr)
+		// validators possibly set for a successful response must not
+		// make the error cacheable
+		ctx.Writer.Header().Del("ETag")
+		ctx.Header("Cache-Control", "no-store")
+		if appErr.Type == core.ErrorTypeModelLoading {
+			ctx.Header("Retry-After", strconv.Itoa(modelLoadingRetryAfterSecs))
+		}
+		message := appErr.Message
+		if appErr.Type != core.ErrorTypeInternalError {
+			// internal causes are only logged
+			message = appErr.Error()
+		}
+		uniresp.WriteJSONResponseWithStatus(
+			ctx.Writer,
+			status,
+			ErrorEnvelope{
+				Error: ErrorDetail{
+					Code:      appErr.Type,
+					Message:   message,
+					Param:     appErr.Param,
+					RequestID: RequestID(ctx),
+				},
+			},
+		)
+	}
+}
+
+// NoRouteHandler reports unknown API paths using the error envelope
+func NoRouteHandler(ctx *gin.Context) {
+	respondWithError(ctx, core.NewAppError("no such API endpoint", core.ErrorTypeNotFound, nil))
+}
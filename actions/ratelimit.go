@@ -0,0 +1,254 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/openapi"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// idleLimiterTTL specifies how long a limiter of an inactive
+	// client is kept
+	idleLimiterTTL = 10 * time.Minute
+
+	// maxMCPClassifiedBodySize is the largest MCP request body
+	// inspected for tool calls. Larger bodies count as tool calls.
+	maxMCPClassifiedBodySize = 1 << 20
+
+	mcpToolCallMethod = "tools/call"
+)
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// clientLimiters holds a token bucket for each client
+type clientLimiters struct {
+	items     map[string]*limiterEntry
+	lastPurge time.Time
+	mu        sync.Mutex
+}
+
+// reserve takes a token from the client's bucket (creating the bucket
+// using the rule if needed). If no token is available, a time to wait
+// is returned.
+func (cl *clientLimiters) reserve(clientID string, rule config.RateLimitRule) (time.Duration, bool) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	now := time.Now()
+	if now.Sub(cl.lastPurge) > idleLimiterTTL {
+		for k, v := range cl.items {
+			if now.Sub(v.lastSeen) > idleLimiterTTL {
+				delete(cl.items, k)
+			}
+		}
+		cl.lastPurge = now
+	}
+	entry, ok := cl.items[clientID]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(rate.Limit(rule.RequestsPerSec), rule.Burst)}
+		cl.items[clientID] = entry
+	}
+	entry.lastSeen = now
+	r := entry.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return time.Duration(math.MaxInt64), false
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+func newClientLimiters() *clientLimiters {
+	return &clientLimiters{items: make(map[string]*limiterEntry)}
+}
+
+// RateLimiter limits request rates of individual clients. Clients
+// are identified by their API key name (if authenticated) or by their
+// address. Expensive routes use separate buckets.
+type RateLimiter struct {
+	conf      config.RateLimitConf
+	regular   *clientLimiters
+	expensive *clientLimiters
+}
+
+// clientID returns a client identification and the key
+// the client's rate limit overrides are configured by
+func (rl *RateLimiter) clientID(req *http.Request) (string, string) {
+	if principal := auth.FromContext(req.Context()); principal != nil && !principal.Anonymous {
+		return "key:" + principal.Name, principal.Name
+	}
+	addr := getClientAddress(req)
+	return "addr:" + addr, addr
+}
+
+// rule returns effective limits for a client. Zero values
+// of an override are replaced by the general ones.
+func (rl *RateLimiter) rule(overrideKey string, expensive bool) config.RateLimitRule {
+	ans := rl.conf.Default
+	if expensive {
+		ans = rl.conf.Expensive
+	}
+	override, ok := rl.conf.ClientOverrides[overrideKey]
+	if !ok {
+		return ans
+	}
+	orule := override.Default
+	if expensive {
+		orule = override.Expensive
+	}
+	if orule.RequestsPerSec > 0 {
+		ans.RequestsPerSec = orule.RequestsPerSec
+	}
+	if orule.Burst > 0 {
+		ans.Burst = orule.Burst
+	}
+	return ans
+}
+
+// wrap applies the rate limiting to a handler. Rejected requests
+// get 429 Too Many Requests with the Retry-After header.
+func (rl *RateLimiter) wrap(expensive bool, handler gin.HandlerFunc) gin.HandlerFunc {
+	limiters := rl.regular
+	if expensive {
+		limiters = rl.expensive
+	}
+	return func(ctx *gin.Context) {
+		clientID, overrideKey := rl.clientID(ctx.Request)
+		delay, ok := limiters.reserve(clientID, rl.rule(overrideKey, expensive))
+		if !ok {
+			retryAfter := int64(math.Ceil(delay.Seconds()))
+			if delay == time.Duration(math.MaxInt64) {
+				// the request can never pass (e.g. zero burst)
+				retryAfter = int64(idleLimiterTTL.Seconds())
+			}
+			ctx.Header("Retry-After", strconv.FormatInt(max(1, retryAfter), 10))
+			respondWithError(
+				ctx,
+				core.NewAppError("too many requests", core.ErrorTypeRateLimited, nil),
+			)
+			return
+		}
+		handler(ctx)
+	}
+}
+
+// NewRateLimiter is a recommended factory function for RateLimiter
+func NewRateLimiter(conf config.RateLimitConf) *RateLimiter {
+	return &RateLimiter{
+		conf:      conf,
+		regular:   newClientLimiters(),
+		expensive: newClientLimiters(),
+	}
+}
+
+// applyRateLimits wraps handlers of all the routes by the rate
+// limiter. Similarity and collocation queries count as expensive.
+func (a *ActionHandler) applyRateLimits(routes []openapi.Route) []openapi.Route {
+	if a.rateLimiter == nil {
+		return routes
+	}
+	expensive := []string{
+		"collocations",
+		"collocationsWithPoS",
+		"collocationsOfType",
+		"collocationsOfTypeWithPoS",
+		"similarWords",
+		"similarWordsWithFn",
+	}
+	for i, route := range routes {
+		routes[i].Handler = a.rateLimiter.wrap(
+			slices.Contains(expensive, route.Operation.OperationID), route.Handler)
+		routes[i].Operation.Responses["429"] = errResponse("rate limit exceeded (see the Retry-After header)")
+	}
+	return routes
+}
+
+// isMCPToolCall tells whether an MCP request (a single JSON-RPC
+// message or a batch) contains a tool call. The request body
+// is restored so the MCP transport can read it again.
+func isMCPToolCall(req *http.Request) bool {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxMCPClassifiedBodySize+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	if err != nil || len(body) > maxMCPClassifiedBodySize {
+		return true
+	}
+	type rpcMessage struct {
+		Method string `json:"method"`
+	}
+	var messages []rpcMessage
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return false
+		}
+
+	} else {
+		var msg rpcMessage
+		if err := json.Unmarshal(trimmed, &msg); err != nil {
+			return false
+		}
+		messages = append(messages, msg)
+	}
+	return slices.ContainsFunc(messages, func(m rpcMessage) bool {
+		return m.Method == mcpToolCallMethod
+	})
+}
+
+// MCPRateLimitMiddleware applies the rate limiting to the MCP
+// endpoints mounted in the server. Tool calls count as expensive,
+// other MCP requests (initialization, listings, streams) as regular.
+func (a *ActionHandler) MCPRateLimitMiddleware() gin.HandlerFunc {
+	if a.rateLimiter == nil {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+	regular := a.rateLimiter.wrap(false, func(ctx *gin.Context) { ctx.Next() })
+	expensive := a.rateLimiter.wrap(true, func(ctx *gin.Context) { ctx.Next() })
+	return func(ctx *gin.Context) {
+		if isMCPToolCall(ctx.Request) {
+			expensive(ctx)
+
+		} else {
+			regular(ctx)
+		}
+	}
+}
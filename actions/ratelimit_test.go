@@ -0,0 +1,254 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowRule allows only the burst within a test run
+func slowRule(burst int) config.RateLimitRule {
+	return config.RateLimitRule{RequestsPerSec: 0.001, Burst: burst}
+}
+
+func newRateLimitedEngine(t *testing.T, conf config.RateLimitConf) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authn, err := auth.NewAuthenticator(config.AuthConf{
+		Enabled:        true,
+		PublicDatasets: []string{config.AuthAllDatasets},
+		APIKeys: []config.APIKeyConf{
+			{Name: "k1", Key: "secret1", Datasets: []string{config.AuthAllDatasets}},
+			{Name: "k2", Key: "secret2", Datasets: []string{config.AuthAllDatasets}},
+			{Name: "vip", Key: "secret3", Datasets: []string{config.AuthAllDatasets}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := NewRateLimiter(conf)
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	engine := gin.New()
+	engine.Use(ErrorMiddleware())
+	engine.Use(AuthMiddleware(authn))
+	engine.GET("/regular", rl.wrap(false, ok))
+	engine.GET("/expensive", rl.wrap(true, ok))
+	return engine
+}
+
+func TestRateLimiterRejectsAfterBurst(t *testing.T) {
+	engine := newRateLimitedEngine(t, config.RateLimitConf{
+		Enabled: true,
+		ClientRateLimits: config.ClientRateLimits{
+			Default:   slowRule(2),
+			Expensive: slowRule(1),
+		},
+	})
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/regular", http.StatusOK},
+		{"/regular", http.StatusOK},
+		{"/regular", http.StatusTooManyRequests},
+		// expensive routes have their own buckets
+		{"/expensive", http.StatusOK},
+		{"/expensive", http.StatusTooManyRequests},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = "192.0.2.1:5000"
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tt.wantStatus, w.Code, i)
+		if tt.wantStatus != http.StatusTooManyRequests {
+			assert.Empty(t, w.Header().Get("Retry-After"), i)
+			continue
+		}
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		assert.NoError(t, err, i)
+		assert.GreaterOrEqual(t, retryAfter, 1, i)
+		var resp ErrorEnvelope
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), i)
+		assert.Equal(t, core.ErrorTypeRateLimited, resp.Error.Code, i)
+	}
+}
+
+func TestRateLimiterZeroBurst(t *testing.T) {
+	engine := newRateLimitedEngine(t, config.RateLimitConf{
+		Enabled:          true,
+		ClientRateLimits: config.ClientRateLimits{Default: slowRule(0)},
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/regular", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, strconv.Itoa(int(idleLimiterTTL.Seconds())), w.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	engine := newRateLimitedEngine(t, config.RateLimitConf{
+		Enabled:          true,
+		ClientRateLimits: config.ClientRateLimits{Default: slowRule(1)},
+		ClientOverrides: map[string]config.ClientRateLimits{
+			"vip":       {Default: config.RateLimitRule{Burst: 2}},
+			"192.0.2.9": {Default: config.RateLimitRule{Burst: 2}},
+		},
+	})
+	tests := []struct {
+		name       string
+		remoteAddr string
+		apiKey     string
+		wantStatus int
+	}{
+		{"first anonymous request", "192.0.2.1:5000", "", http.StatusOK},
+		{"same address", "192.0.2.1:5001", "", http.StatusTooManyRequests},
+		{"different address", "192.0.2.2:5000", "", http.StatusOK},
+		{"key from a limited address", "192.0.2.1:5000", "secret1", http.StatusOK},
+		{"same key from another address", "192.0.2.3:5000", "secret1", http.StatusTooManyRequests},
+		{"another key", "192.0.2.3:5000", "secret2", http.StatusOK},
+		{"address of a used key", "192.0.2.3:5000", "", http.StatusOK},
+		{"key override 1", "192.0.2.4:5000", "secret3", http.StatusOK},
+		{"key override 2", "192.0.2.5:5000", "secret3", http.StatusOK},
+		{"key override exhausted", "192.0.2.4:5000", "secret3", http.StatusTooManyRequests},
+		{"address override 1", "192.0.2.9:5000", "", http.StatusOK},
+		{"address override 2", "192.0.2.9:5000", "", http.StatusOK},
+		{"address override exhausted", "192.0.2.9:5000", "", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/regular", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.apiKey != "" {
+			req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tt.wantStatus, w.Code, tt.name)
+	}
+}
+
+func TestIsMCPToolCall(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   bool
+	}{
+		{
+			name:   "tool call",
+			method: http.MethodPost,
+			body:   `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"similar_words"}}`,
+			want:   true,
+		},
+		{
+			name:   "tool listing",
+			method: http.MethodPost,
+			body:   `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		},
+		{
+			name:   "batch with a tool call",
+			method: http.MethodPost,
+			body:   ` [{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","id":2,"method":"tools/call"}]`,
+			want:   true,
+		},
+		{
+			name:   "batch without a tool call",
+			method: http.MethodPost,
+			body:   `[{"jsonrpc":"2.0","id":1,"method":"initialize"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+		},
+		{
+			name:   "invalid JSON",
+			method: http.MethodPost,
+			body:   `{"method":"tools/call"`,
+		},
+		{
+			name:   "stream",
+			method: http.MethodGet,
+		},
+		{
+			name:   "oversized body",
+			method: http.MethodPost,
+			body:   `{"method":"tools/list","pad":"` + strings.Repeat("x", maxMCPClassifiedBodySize) + `"}`,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/mcp", strings.NewReader(tt.body))
+		assert.Equal(t, tt.want, isMCPToolCall(req), tt.name)
+		// the transport must get the whole body
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.body, string(body), tt.name)
+	}
+}
+
+func TestMCPRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &ActionHandler{rateLimiter: NewRateLimiter(config.RateLimitConf{
+		Enabled: true,
+		ClientRateLimits: config.ClientRateLimits{
+			Default:   slowRule(10),
+			Expensive: slowRule(1),
+		},
+	})}
+	engine := gin.New()
+	engine.Use(ErrorMiddleware())
+	engine.POST("/mcp", handler.MCPRateLimitMiddleware(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	listTools := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	callTool := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"similar_words"}}`
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"listing", listTools, http.StatusOK},
+		{"first tool call", callTool, http.StatusOK},
+		{"second tool call", callTool, http.StatusTooManyRequests},
+		{"listing is not expensive", listTools, http.StatusOK},
+		{"another listing", listTools, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tt.body))
+		req.RemoteAddr = "192.0.2.1:5000"
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tt.wantStatus, w.Code, tt.name)
+	}
+}
+
+func TestMCPRateLimitMiddlewareDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/mcp", (&ActionHandler{}).MCPRateLimitMiddleware(), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"method":"tools/call"}`)))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/openapi"
+	"github.com/czcorpus/wsserver/queries"
+)
+
+const (
+	// APIBasePath is a path prefix of the current API version.
+	// Routes are also available without the prefix for backward
+	// compatibility.
+	APIBasePath = "/v1"
+
+	dfltLimit = 10
+
+	// serviceTag marks routes providing information about the service
+	// itself (available only to admins in case authentication is enabled)
+	serviceTag = "service"
+)
+
+var (
+	sortingMeasures   = []string{"rrf", "ldice", "tscore", "lmi"}
+	predefinedSearchs = []string{
+		string(scoll.ModifiersOf),
+		string(scoll.NounsModifiedBy),
+		string(scoll.VerbsSubject),
+		string(scoll.VerbsObject),
+	}
+)
+
+// APISchemas returns JSON schemas of all the API response types
+func APISchemas() map[string]*openapi.Schema {
+	return map[string]*openapi.Schema{
+		"ResultRow":             openapi.SchemaOf(queries.ResultRow{}),
+		"SimpleCollocation":     openapi.SchemaOf(queries.SimpleCollocation{}),
+		"CollocationsResponse":  openapi.SchemaOf(collResponse{}),
+		"DictItem":              openapi.SchemaOf(queries.DictItem{}),
+		"ModelInfo":             openapi.SchemaOf(model.ModelInfo{}),
+		"ModelConf":             openapi.SchemaOf(model.ModelConf{}),
+		"DatasetInfo":           openapi.SchemaOf(queries.DatasetInfo{}),
+		"CacheStats":            openapi.SchemaOf(queries.CacheStats{}),
+		openapi.ErrorSchemaName: openapi.SchemaOf(ErrorEnvelope{}),
+	}
+}
+
+func errResponse(description string) openapi.Response {
+	return openapi.JSONResponse(description, openapi.Ref(openapi.ErrorSchemaName))
+}
+
+func datasetParam() openapi.Param {
+	return openapi.PathParam("corpusId", "Dataset (corpus) ID")
+}
+
+func limitParam() openapi.Param {
+	return openapi.QueryParam(
+		"limit",
+		"Maximum number of returned items",
+		&openapi.Schema{Type: openapi.TypeInteger, Minimum: json.Number("1"), Default: dfltLimit},
+	)
+}
+
+func collQueryParams() []openapi.Param {
+	return []openapi.Param{
+		limitParam(),
+		openapi.QueryParam(
+			"sortBy",
+			"Association measure to sort results by",
+			&openapi.Schema{Type: openapi.TypeString, Enum: toAnySlice(sortingMeasures), Default: "rrf"},
+		),
+		openapi.QueryParam("tt", "Text type to restrict the search to", &openapi.Schema{Type: openapi.TypeString}),
+		openapi.QueryParam(
+			"collDb",
+			"Collocation database ID (the dataset's default one is used if omitted)",
+			&openapi.Schema{Type: openapi.TypeString},
+		),
+	}
+}
+
+func toAnySlice(values []string) []any {
+	ans := make([]any, len(values))
+	for i, v := range values {
+		ans[i] = v
+	}
+	return ans
+}
+
+// Routes returns all the API routes along with their OpenAPI description
+func (a *ActionHandler) Routes() []openapi.Route {
+	notFound := errResponse("dataset, model or collocation database not found")
+	timeout := errResponse("query deadline exceeded")
+	modelLoading := errResponse("the model is being loaded (see the Retry-After header)")
+	unsupported := errResponse("the dataset or model does not support the requested feature")
+	collocationsResp := openapi.JSONResponse(
+		"found collocations", openapi.Ref("CollocationsResponse"))
+	similarWordsParams := []openapi.Param{
+		datasetParam(),
+		openapi.PathParam("modelId", "Word embeddings model ID"),
+		openapi.PathParam("word", "Word to find similarly used words for"),
+		limitParam(),
+		openapi.QueryParam(
+			"minScore",
+			"Minimum similarity score of returned words",
+			&openapi.Schema{Type: openapi.TypeNumber, Default: 0},
+		),
+	}
+	routes := []openapi.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/datasets",
+			Handler: a.HandleDatasetList,
+			Operation: openapi.Operation{
+				OperationID: "listDatasets",
+				Summary:     "List all the datasets along with their models, collocation databases and features",
+				Tags:        []string{"datasets"},
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("datasets", openapi.ArrayOf(openapi.Ref("DatasetInfo"))),
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/cache",
+			Handler: a.HandleCacheStats,
+			Operation: openapi.Operation{
+				OperationID: "cacheStats",
+				Summary:     "Get statistics of the query result cache",
+				Tags:        []string{serviceTag},
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("cache statistics", openapi.Ref("CacheStats")),
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/dictionary/:word",
+			Handler: withDeadline(a.deadlines.DictionarySecs, a.Dictionary),
+			Operation: openapi.Operation{
+				OperationID: "dictionary",
+				Summary:     "Get parts of speech, text types and frequencies of a lemma",
+				Tags:        []string{"collocations"},
+				Parameters: []openapi.Param{
+					datasetParam(),
+					openapi.PathParam("word", "Lemma to look up"),
+					openapi.QueryParam(
+						"collDb",
+						"Collocation database ID (the dataset's default one is used if omitted)",
+						&openapi.Schema{Type: openapi.TypeString},
+					),
+				},
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("dictionary items", openapi.ArrayOf(openapi.Ref("DictItem"))),
+					"404": notFound,
+					"504": timeout,
+					"422": unsupported,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/collocations/:word/:pos",
+			Handler: withDeadline(a.deadlines.CollocationsSecs, a.Collocations),
+			Operation: openapi.Operation{
+				OperationID: "collocationsWithPoS",
+				Summary:     "Find syntactic collocations of a lemma with the specified PoS",
+				Tags:        []string{"collocations"},
+				Parameters: append(
+					[]openapi.Param{
+						datasetParam(),
+						openapi.PathParam("word", "Lemma to find collocations for"),
+						openapi.PathParam("pos", "Universal Dependencies PoS tag of the lemma"),
+					},
+					collQueryParams()...,
+				),
+				Responses: map[string]openapi.Response{
+					"200": collocationsResp,
+					"404": notFound,
+					"504": timeout,
+					"422": unsupported,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/collocations/:word",
+			Handler: withDeadline(a.deadlines.CollocationsSecs, a.Collocations),
+			Operation: openapi.Operation{
+				OperationID: "collocations",
+				Summary:     "Find syntactic collocations of a lemma",
+				Tags:        []string{"collocations"},
+				Parameters: append(
+					[]openapi.Param{
+						datasetParam(),
+						openapi.PathParam("word", "Lemma to find collocations for"),
+					},
+					collQueryParams()...,
+				),
+				Responses: map[string]openapi.Response{
+					"200": collocationsResp,
+					"404": notFound,
+					"504": timeout,
+					"422": unsupported,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/collocationsOfType/:type/:word/:pos",
+			Handler: withDeadline(a.deadlines.CollocationsSecs, a.CollocationsOfType),
+			Operation: openapi.Operation{
+				OperationID: "collocationsOfTypeWithPoS",
+				Summary:     "Find collocations of a lemma with the specified PoS in a predefined syntactic relation",
+				Tags:        []string{"collocations"},
+				Parameters: append(
+					[]openapi.Param{
+						datasetParam(),
+						openapi.PathParam("type", "Type of the syntactic relation", predefinedSearchs...),
+						openapi.PathParam("word", "Lemma to find collocations for"),
+						openapi.PathParam("pos", "Universal Dependencies PoS tag of the lemma"),
+					},
+					collQueryParams()...,
+				),
+				Responses: map[string]openapi.Response{
+					"200": collocationsResp,
+					"404": notFound,
+					"504": timeout,
+					"422": unsupported,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/collocationsOfType/:type/:word",
+			Handler: withDeadline(a.deadlines.CollocationsSecs, a.CollocationsOfType),
+			Operation: openapi.Operation{
+				OperationID: "collocationsOfType",
+				Summary:     "Find collocations of a lemma in a predefined syntactic relation",
+				Tags:        []string{"collocations"},
+				Parameters: append(
+					[]openapi.Param{
+						datasetParam(),
+						openapi.PathParam("type", "Type of the syntactic relation", predefinedSearchs...),
+						openapi.PathParam("word", "Lemma to find collocations for"),
+					},
+					collQueryParams()...,
+				),
+				Responses: map[string]openapi.Response{
+					"200": collocationsResp,
+					"404": notFound,
+					"504": timeout,
+					"422": unsupported,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/similarWords/:modelId",
+			Handler: a.HandleModelInfo,
+			Operation: openapi.Operation{
+				OperationID: "modelInfo",
+				Summary:     "Get configuration of a word embeddings model",
+				Tags:        []string{"similarWords"},
+				Parameters: []openapi.Param{
+					datasetParam(),
+					openapi.PathParam("modelId", "Word embeddings model ID"),
+				},
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("model configuration", openapi.Ref("ModelConf")),
+					"404": notFound,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/similarWords/:modelId/:word/:fn",
+			Handler: withDeadline(a.deadlines.SimilarWordsSecs, a.WordSimilarity),
+			Operation: openapi.Operation{
+				OperationID: "similarWordsWithFn",
+				Summary:     "Find words similarly used as a word with the specified PoS or syntactic function",
+				Tags:        []string{"similarWords"},
+				Parameters: slices.Concat(
+					similarWordsParams,
+					[]openapi.Param{openapi.PathParam("fn", "PoS or syntactic function of the word")},
+				),
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("similar words", openapi.ArrayOf(openapi.Ref("ResultRow"))),
+					"404": notFound,
+					"504": timeout,
+					"422": unsupported,
+					"503": modelLoading,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/similarWords/:modelId/:word",
+			Handler: withDeadline(a.deadlines.SimilarWordsSecs, a.WordSimilarity),
+			Operation: openapi.Operation{
+				OperationID: "similarWords",
+				Summary:     "Find words similarly used as a word",
+				Tags:        []string{"similarWords"},
+				Parameters:  similarWordsParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("similar words", openapi.ArrayOf(openapi.Ref("ResultRow"))),
+					"404": notFound,
+					"504": timeout,
+					"503": modelLoading,
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/dataset/:corpusId/similarWords",
+			Handler: a.HandleModelList,
+			Operation: openapi.Operation{
+				OperationID: "listModels",
+				Summary:     "List word embeddings models of a dataset (the models get loaded)",
+				Tags:        []string{"similarWords"},
+				Parameters:  []openapi.Param{datasetParam()},
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("models", openapi.ArrayOf(openapi.Ref("ModelInfo"))),
+				},
+			},
+		},
+	}
+	return a.applyRateLimits(a.applyAccessControl(a.applyHTTPCaching(routes)))
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth provides API key authentication and per-dataset
+// access control. An authenticated client (a Principal) is passed
+// along with a request context so all the layers serving the request
+// (REST handlers, MCP tools, searchers) can check access.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+)
+
+const (
+	// APIKeyHeader is a request header API clients pass their key in.
+	// Alternatively, the "Authorization: Bearer [key]" header can be used.
+	APIKeyHeader = "X-API-Key"
+
+	anonymousName = "anonymous"
+)
+
+type principalCtxKey struct{}
+
+// KeyFromRequest returns an API key provided by a client (if any)
+func KeyFromRequest(req *http.Request) string {
+	if key := req.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if authHeader := req.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	}
+	return ""
+}
+
+// Principal is an authenticated client (or an anonymous one)
+// along with datasets and models it can access. A nil Principal
+// means authentication is disabled and everything is accessible.
+type Principal struct {
+	Name        string
+	Anonymous   bool
+	Admin       bool
+	allDatasets bool
+	datasets    []string
+
+	// models maps dataset IDs to allowed models. Datasets
+	// without an entry have all their models allowed.
+	models map[string][]string
+}
+
+// CanAccessDataset tells whether the principal can access the dataset
+func (p *Principal) CanAccessDataset(datasetID string) bool {
+	if p == nil {
+		return true
+	}
+	return p.allDatasets || slices.Contains(p.datasets, datasetID)
+}
+
+// CanAccessModel tells whether the principal can access a model
+// of a dataset
+func (p *Principal) CanAccessModel(datasetID, modelID string) bool {
+	if !p.CanAccessDataset(datasetID) {
+		return false
+	}
+	if p == nil {
+		return true
+	}
+	models, ok := p.models[datasetID]
+	return !ok || slices.Contains(models, modelID)
+}
+
+// CanAccessService tells whether the principal can access
+// service endpoints (e.g. cache statistics)
+func (p *Principal) CanAccessService() bool {
+	return p == nil || p.Admin
+}
+
+// DeniedError creates an error for a denied access to the resource.
+// For an anonymous principal, the error suggests providing an API key.
+func (p *Principal) DeniedError(resource string) core.AppError {
+	if p.Anonymous {
+		return core.NewAppError(
+			fmt.Sprintf("access to %s requires an API key", resource),
+			core.ErrorTypeUnauthorized,
+			nil,
+		)
+	}
+	return core.NewAppError(
+		fmt.Sprintf("access to %s denied", resource),
+		core.ErrorTypeForbidden,
+		nil,
+	)
+}
+
+func newPrincipal(name string, datasets, models []string) *Principal {
+	ans := &Principal{
+		Name:     name,
+		datasets: make([]string, 0, len(datasets)),
+		models:   make(map[string][]string),
+	}
+	for _, d := range datasets {
+		if d == config.AuthAllDatasets {
+			ans.allDatasets = true
+
+		} else {
+			ans.datasets = append(ans.datasets, d)
+		}
+	}
+	for _, m := range models {
+		datasetID, modelID, _ := strings.Cut(m, "/")
+		ans.models[datasetID] = append(ans.models[datasetID], modelID)
+	}
+	return ans
+}
+
+// WithPrincipal attaches a principal to a context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, p)
+}
+
+// FromContext returns a principal attached to the context. In case
+// there is none (e.g. authentication is disabled), nil is returned
+// which is a valid unrestricted principal.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalCtxKey{}).(*Principal)
+	return p
+}
+
+// CheckAccess tests whether the client of a request (see FromContext)
+// can access the dataset and, if modelID is not empty, the model
+func CheckAccess(ctx context.Context, datasetID, modelID string) core.AppError {
+	principal := FromContext(ctx)
+	if !principal.CanAccessDataset(datasetID) {
+		return principal.DeniedError(fmt.Sprintf("dataset %s", datasetID)).WithParam("corpusId")
+	}
+	if modelID != "" && !principal.CanAccessModel(datasetID, modelID) {
+		return principal.DeniedError(fmt.Sprintf("model %s", modelID)).WithParam("modelId")
+	}
+	return core.AppError{}
+}
+
+// CheckServiceAccess tests whether the client of a request
+// (see FromContext) can access service endpoints
+func CheckServiceAccess(ctx context.Context) core.AppError {
+	principal := FromContext(ctx)
+	if !principal.CanAccessService() {
+		return principal.DeniedError("service endpoints")
+	}
+	return core.AppError{}
+}
+
+// -------------------
+
+type keyEntry struct {
+	hash      []byte
+	principal *Principal
+}
+
+// Authenticator maps API keys to principals
+type Authenticator struct {
+	enabled   bool
+	anonymous *Principal
+	keys      []keyEntry
+}
+
+// Enabled tells whether the authentication is enabled
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// Authenticate finds a principal for an API key. An empty key means
+// an anonymous client with access to public datasets only. For
+// disabled authentication, nil principal is returned.
+func (a *Authenticator) Authenticate(key string) (*Principal, core.AppError) {
+	if !a.enabled {
+		return nil, core.AppError{}
+	}
+	if key == "" {
+		return a.anonymous, core.AppError{}
+	}
+	hash := sha256.Sum256([]byte(key))
+	var ans *Principal
+	// all the keys are compared to keep the timing constant
+	for _, entry := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], entry.hash) == 1 {
+			ans = entry.principal
+		}
+	}
+	if ans == nil {
+		return nil, core.NewAppError("invalid API key", core.ErrorTypeUnauthorized, nil)
+	}
+	return ans, core.AppError{}
+}
+
+// NewAuthenticator is a recommended factory function for Authenticator
+func NewAuthenticator(conf config.AuthConf) (*Authenticator, error) {
+	ans := &Authenticator{
+		enabled:   conf.Enabled,
+		anonymous: newPrincipal(anonymousName, conf.PublicDatasets, nil),
+		keys:      make([]keyEntry, 0, len(conf.APIKeys)),
+	}
+	ans.anonymous.Anonymous = true
+	for _, kc := range conf.APIKeys {
+		var hash []byte
+		if kc.Key != "" {
+			sum := sha256.Sum256([]byte(kc.Key))
+			hash = sum[:]
+
+		} else {
+			var err error
+			hash, err = hex.DecodeString(kc.KeySHA256)
+			if err != nil || len(hash) != sha256.Size {
+				return nil, fmt.Errorf("invalid keySha256 of API key %s", kc.Name)
+			}
+		}
+		principal := newPrincipal(kc.Name, kc.Datasets, kc.Models)
+		principal.Admin = kc.Admin
+		ans.keys = append(ans.keys, keyEntry{
+			hash:      hash,
+			principal: principal,
+		})
+	}
+	return ans, nil
+}
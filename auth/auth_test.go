@@ -0,0 +1,55 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckServiceAccess(t *testing.T) {
+	authn, err := NewAuthenticator(config.AuthConf{
+		Enabled:        true,
+		PublicDatasets: []string{config.AuthAllDatasets},
+		APIKeys: []config.APIKeyConf{
+			{Name: "admin", Key: "k-admin", Datasets: []string{config.AuthAllDatasets}, Admin: true},
+			{Name: "user", Key: "k-user", Datasets: []string{config.AuthAllDatasets}},
+		},
+	})
+	assert.NoError(t, err)
+	tests := []struct {
+		name     string
+		key      string
+		wantType core.ErrorType
+	}{
+		{"anonymous", "", core.ErrorTypeUnauthorized},
+		{"regular key", "k-user", core.ErrorTypeForbidden},
+		{"admin key", "k-admin", ""},
+	}
+	for _, tt := range tests {
+		principal, appErr := authn.Authenticate(tt.key)
+		assert.True(t, appErr.IsZero(), tt.name)
+		appErr = CheckServiceAccess(WithPrincipal(context.Background(), principal))
+		assert.Equal(t, tt.wantType, appErr.Type, tt.name)
+	}
+	// disabled authentication means no principal
+	assert.True(t, CheckServiceAccess(context.Background()).IsZero())
+}
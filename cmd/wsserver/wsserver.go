@@ -18,22 +18,35 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/czcorpus/wsserver/actions"
+	"github.com/czcorpus/wsserver/auth"
 	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/mcp"
+	"github.com/czcorpus/wsserver/metrics"
 	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/netutil"
+	"github.com/czcorpus/wsserver/openapi"
 	"github.com/czcorpus/wsserver/queries"
+	"github.com/czcorpus/wsserver/tracing"
 
 	"github.com/czcorpus/cnc-gokit/logging"
 	"github.com/czcorpus/cnc-gokit/uniresp"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 )
 
@@ -48,118 +61,277 @@ var (
 	}
 )
 
-func main() {
-	flag.Parse()
+func validateConfig(confPath string, cli *config.CLIOverrides) int {
+	conf, err := config.LoadEffective(confPath, cli)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	problems := config.Validate(conf)
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "%s\n", p)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "found %d problem(s) in %s\n", len(problems), confPath)
+		return 1
+	}
+	fmt.Printf("config %s is valid\n", confPath)
+	return 0
+}
+
+func printConfig(confPath string, cli *config.CLIOverrides) int {
+	conf, err := config.LoadEffective(confPath, cli)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	config.ApplyDefaults(conf)
+	data, err := config.PrintableJSON(conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode config: %s\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+func runServer(confPath string, cli *config.CLIOverrides) {
+	conf, err := config.LoadEffective(confPath, cli)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %s\n", err)
+		os.Exit(1)
+	}
+	if problems := config.Validate(conf); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "Invalid config: %s\n", p)
+		}
+		os.Exit(1)
+	}
+	logging.SetupLogging(conf.Logging)
+	config.ApplyDefaults(conf)
+
+	shutdownTracing, err := tracing.Setup(
+		context.Background(), conf.Tracing, "wsserver", versionInfo.Version, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up tracing: %s\n", err)
+		os.Exit(1)
+	}
+
+	authn, err := auth.NewAuthenticator(conf.Auth)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up authentication: %s\n", err)
+		os.Exit(1)
+	}
+
+	trustedProxies, err := conf.TrustedProxyPrefixes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %s\n", err)
+		os.Exit(1)
+	}
 
 	engine := gin.New()
+	// client addresses are resolved by ClientAddressMiddleware
+	engine.ForwardedByClientIP = false
 	engine.Use(gin.Recovery())
+	engine.Use(actions.ClientAddressMiddleware(actions.NewClientAddrResolver(trustedProxies, conf.ForwardedHeader)))
+	engine.Use(tracing.GinMiddleware("wsserver", metrics.Path))
 	engine.Use(logging.GinMiddleware())
+	engine.Use(actions.RequestIDMiddleware())
+	if !conf.Metrics.Disabled {
+		var streamRoutes []string
+		if conf.MCP.MountInServer {
+			streamRoutes = mcp.StreamPaths(conf.MCP.BasePath)
+		}
+		engine.Use(metrics.Middleware(
+			metrics.NewLabelFilter(slices.Collect(maps.Keys(conf.Corpora)), conf.Models),
+			streamRoutes...,
+		))
+	}
 	engine.Use(uniresp.AlwaysJSONContentType())
+	engine.Use(actions.ErrorMiddleware())
+	if conf.CORS.Enabled() {
+		engine.Use(actions.CORSMiddleware(conf.CORS))
+	}
+	engine.Use(actions.AuthMiddleware(authn))
+	engine.NoRoute(actions.NoRouteHandler)
 
-	if flag.Arg(0) != "" {
-		conf, err := config.Load(flag.Arg(0))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to load config: %s\n", err)
-			os.Exit(1)
-		}
-		logging.SetupLogging(conf.Logging)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-		go func() {
-			<-ctx.Done()
-			stop()
-		}()
+	collDbConfs, problems := conf.EffectiveCollDatabases()
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Invalid collocation databases configuration: %s\n", errors.Join(problems...))
+		os.Exit(1)
+	}
+	collDbMap, err := queries.NewCollDbMap(conf.PathResolver(), collDbConfs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to instantiate collocation databases: %s\n", err)
+		os.Exit(1)
+	}
 
-		collDbMap, err := queries.NewCollDbMap(conf.Models)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to instantiate collocation databases: %s\n", err)
-			os.Exit(1)
-		}
+	// fail reports a startup error and exits. Databases are closed
+	// first so they do not need a recovery on the next start.
+	fail := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, format, args...)
+		collDbMap.Close()
+		os.Exit(1)
+	}
 
-		w2vModels := model.NewProvider(conf.DataDir, conf.Models)
+	w2vModels := model.NewProvider(conf.PathResolver(), conf.Models, conf.RateLimit.MaxConcurrentScans)
 
-		searcher, err := queries.NewSearchProvider(
-			conf.DataDir,
-			collDbMap,
-			w2vModels,
-		)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to instantiate searcher: %s\n", err)
-			os.Exit(1)
-		}
+	searcher, err := queries.NewSearchProvider(
+		conf.DataDir,
+		collDbMap,
+		w2vModels,
+		conf.Corpora,
+		queries.NewResultCache(conf.ResultCache),
+	)
+	if err != nil {
+		fail("Failed to instantiate searcher: %s\n", err)
+	}
 
-		log.Printf("INFO: starting to listen on %s:%d", conf.ListenAddress, conf.ListenPort)
-		handler, err := actions.NewActionHandler(conf.DataDir, w2vModels, searcher)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to instantiate API handler: %s\n", err)
-			os.Exit(1)
-		}
+	if !conf.Metrics.Disabled {
+		w2vModels.OnLoad(metrics.ObserveModelLoad)
+		metrics.RegisterModelProvider(w2vModels)
+		prometheus.MustRegister(queries.NewCacheCollector(searcher))
+		engine.GET(metrics.Path, gin.WrapH(promhttp.Handler()))
+	}
 
-		engine.GET(
-			"/dataset/:corpusId/dictionary/:word",
-			handler.Dictionary,
-		)
+	log.Printf("INFO: starting to listen on %s:%d", conf.ListenAddress, conf.ListenPort)
+	handler, err := actions.NewActionHandler(
+		conf.DataDir, w2vModels, searcher, conf.QueryDeadlines, conf.HTTPCache, conf.RateLimit, authn)
+	if err != nil {
+		fail("Failed to instantiate API handler: %s\n", err)
+	}
 
-		engine.GET(
-			"/dataset/:corpusId/collocations/:word/:pos",
-			handler.Collocations,
-		)
-		engine.GET(
-			"/dataset/:corpusId/collocations/:word",
-			handler.Collocations,
-		)
-		engine.GET(
-			"/dataset/:corpusId/collocationsOfType/:type/:word/:pos",
-			handler.CollocationsOfType,
-		)
-		engine.GET(
-			"/dataset/:corpusId/collocationsOfType/:type/:word",
-			handler.CollocationsOfType,
-		)
-		engine.GET(
-			"/dataset/:corpusId/similarWords/:modelId",
-			handler.HandleModelInfo,
-		)
+	routes := handler.Routes()
+	openapi.RegisterRoutes(engine.Group(actions.APIBasePath), routes)
+	// legacy unversioned aliases
+	openapi.RegisterRoutes(engine, routes)
 
-		engine.GET(
-			"/dataset/:corpusId/similarWords/:modelId/:word/:fn",
-			handler.WordSimilarity,
-		)
-		engine.GET(
-			"/dataset/:corpusId/similarWords/:modelId/:word",
-			handler.WordSimilarity,
-		)
-		engine.GET(
-			"/dataset/:corpusId/similarWords",
-			handler.HandleModelList,
+	apiDoc := openapi.NewDocument(
+		openapi.Info{
+			Title:       "Word-Sim-Service API",
+			Description: "Word similarity and syntactic collocations API",
+			Version:     versionInfo.Version,
+		},
+		actions.APIBasePath,
+		routes,
+		actions.APISchemas(),
+	)
+	engine.GET("/openapi.json", func(ctx *gin.Context) {
+		uniresp.WriteJSONResponse(ctx.Writer, apiDoc)
+	})
+
+	srv := &http.Server{
+		Handler:      engine,
+		Addr:         fmt.Sprintf("%s:%d", conf.ListenAddress, conf.ListenPort),
+		WriteTimeout: time.Duration(conf.ServerWriteTimeoutSecs) * time.Second,
+		ReadTimeout:  time.Duration(conf.ServerReadTimeoutSecs) * time.Second,
+		Protocols:    netutil.ServerProtocols(conf.EnableH2C),
+	}
+
+	if conf.MCP.MountInServer {
+		mcpTransport := mcp.NewHTTPTransport(
+			mcp.NewServer(searcher, conf.Corpora, conf.Models),
+			conf.MCP.BasePath,
+			authn,
 		)
+		mcpTransport.MountGin(engine, handler.MCPRateLimitMiddleware())
+		// MCP streams would otherwise block draining of requests
+		srv.RegisterOnShutdown(mcpTransport.CloseStreams)
+		log.Info().Str("path", conf.MCP.BasePath).Msg("MCP endpoints mounted")
+	}
 
-		srv := &http.Server{
-			Handler:      engine,
-			Addr:         fmt.Sprintf("%s:%d", conf.ListenAddress, conf.ListenPort),
-			WriteTimeout: time.Duration(conf.ServerWriteTimeoutSecs) * time.Second,
-			ReadTimeout:  time.Duration(conf.ServerReadTimeoutSecs) * time.Second,
+	if conf.TLS.Enabled() {
+		srv.TLSConfig, err = netutil.NewServerTLSConfig(ctx, conf.TLS)
+		if err != nil {
+			fail("Failed to set up TLS: %s\n", err)
 		}
+	}
 
+	tcpListener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		fail("Failed to listen on %s: %s\n", srv.Addr, err)
+	}
+	go func() {
+		var err error
+		if conf.TLS.Enabled() {
+			err = srv.ServeTLS(tcpListener, "", "")
+
+		} else {
+			err = srv.Serve(tcpListener)
+		}
+		if err != nil && !netutil.IsServerClosed(err) {
+			log.Error().Err(err).Send()
+		}
+	}()
+	if conf.UnixSocket != "" {
+		unixListener, err := netutil.ListenUnix(conf.UnixSocket)
+		if err != nil {
+			fail("Failed to listen on %s: %s\n", conf.UnixSocket, err)
+		}
+		log.Info().Str("socket", conf.UnixSocket).Msg("listening on Unix socket")
 		go func() {
-			err := srv.ListenAndServe()
-			if err != nil {
+			if err := srv.Serve(unixListener); err != nil && !netutil.IsServerClosed(err) {
 				log.Error().Err(err).Send()
 			}
 		}()
+	}
 
-		<-ctx.Done()
-		log.Info().Err(err).Msg("Shutdown request error")
+	<-ctx.Done()
+	log.Info().Msg("shutdown requested, no longer accepting connections")
 
-		ctxShutDown, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
+	shutdownTimeout := time.Duration(conf.ShutdownTimeoutSecs) * time.Second
+	ctxShutDown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-		if err := srv.Shutdown(ctxShutDown); err != nil {
-			log.Fatal().Err(err).Msg("Server forced to shutdown")
-		}
+	if err := srv.Shutdown(ctxShutDown); err != nil {
+		log.Error().Err(err).Msg("in-flight requests not finished in time, closing connections")
+		srv.Close()
 
 	} else {
+		log.Info().Msg("all in-flight requests finished")
+	}
+	// pending model loads are cancelled at this point so they stop quickly,
+	// the remaining steps get their own timeout in case the previous one used it up
+	ctxRelease, cancelRelease := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelRelease()
+	if err := searcher.Close(ctxRelease); err != nil {
+		log.Error().Err(err).Msg("failed to release data cleanly")
+	}
+	if err := shutdownTracing(ctxRelease); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
+	log.Info().Msg("shutdown complete")
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Word-Sim-Service %s\n\n", versionInfo.Version)
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s [options] config.json\t(start the server)\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "  %s [options] validate-config config.json\t(check config and exit)\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "  %s [options] print-config config.json\t(print effective config and exit)\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "  %s\t(print version info)\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\nPrecedence of configuration values: flags > environment variables > config file > defaults\n\nOptions:\n")
+		flag.PrintDefaults()
+	}
+	cli := config.RegisterOverrideFlags(flag.CommandLine)
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "validate-config":
+		os.Exit(validateConfig(flag.Arg(1), cli))
+	case "print-config":
+		os.Exit(printConfig(flag.Arg(1), cli))
+	case "":
 		fmt.Printf("Word-Sim-Service %s\nbuild date: %s\nlast commit: %s\n",
 			versionInfo.Version, versionInfo.BuildDate, versionInfo.GitCommit)
+	default:
+		runServer(flag.Arg(0), cli)
 	}
 }
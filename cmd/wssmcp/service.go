@@ -6,35 +6,27 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
+	"time"
 
 	"github.com/czcorpus/cnc-gokit/logging"
+	"github.com/czcorpus/wsserver/auth"
 	"github.com/czcorpus/wsserver/config"
-	"github.com/czcorpus/wsserver/core"
-	"github.com/czcorpus/wsserver/model"
-	"github.com/czcorpus/wsserver/queries"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/czcorpus/wsserver/mcp"
+	"github.com/czcorpus/wsserver/tracing"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-type GeneralSearcher interface {
-	SimilarlyUsedWords(
-		ctx context.Context,
-		datasetID, modelID, posOrSfn, word string,
-		limit int,
-		minScore float32,
-	) ([]queries.ResultRow, core.AppError)
-}
-
 func main() {
+	cli := config.RegisterOverrideFlags(flag.CommandLine)
 	flag.Parse()
-	conf, err := config.Load(flag.Arg(0))
+	conf, err := config.LoadEffective(flag.Arg(0), cli)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load config: %s\n", err)
 		os.Exit(1)
 	}
 	logging.SetupLogging(conf.Logging)
+	config.ApplyDefaults(conf)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	go func() {
@@ -42,110 +34,59 @@ func main() {
 		stop()
 	}()
 
-	collDbMap, err := queries.NewCollDbMap(conf.Models)
+	// with the stdio transport, stdout is reserved for the MCP protocol
+	traceOut := os.Stdout
+	if conf.MCP.Transport == config.MCPTransportStdio {
+		traceOut = os.Stderr
+	}
+	shutdownTracing, err := tracing.Setup(ctx, conf.Tracing, "wssmcp", "", traceOut)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to instantiate collocation databases: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to set up tracing: %s\n", err)
 		os.Exit(1)
 	}
-
-	w2vModels := model.NewProvider(conf.DataDir, conf.Models)
-
-	var searcher GeneralSearcher
-	if conf.MCP.SelfContained {
-		searcher, err = queries.NewSearchProvider(
-			conf.DataDir,
-			collDbMap,
-			w2vModels,
-		)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to instantiate searcher: %s\n", err)
-			os.Exit(1)
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush traces: %s\n", err)
 		}
-	} else {
-		searcher = NewHTTPClientSearcher()
+	}()
 
+	searcher, err := mcp.NewSearcher(conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
 	}
 
-	// Create a new MCP server
-	s := server.NewMCPServer(
-		"WSServer",
-		"0.0.2",
-		server.WithToolCapabilities(false),
-		server.WithRecovery(),
-	)
-
-	similarWordsTool := mcp.NewTool("similarly_used_words",
-		mcp.WithDescription("Find words that are similarly used in the dataset"),
-		mcp.WithString("dataset_id",
-			mcp.Required(),
-			mcp.Description("The dataset ID to search in"),
-		),
-		mcp.WithString("model_id",
-			mcp.Required(),
-			mcp.Description("The model ID to use"),
-		),
-		mcp.WithString("pos_or_sfn",
-			mcp.Description("Part of speech or surface form"),
-		),
-		mcp.WithString("word",
-			mcp.Required(),
-			mcp.Description("The word to find similar usage for"),
-		),
-		mcp.WithNumber("limit",
-			mcp.Description("Maximum number of results to return"),
-			// Optional parameter - no mcp.Required()
-		),
-		mcp.WithNumber("min_score",
-			mcp.Description("Minimum similarity score threshold"),
-			// Optional parameter - no mcp.Required()
-		),
-	)
-
-	s.AddTool(
-		similarWordsTool,
-		func(ctx context.Context, request mcp.CallToolRequest,
-		) (*mcp.CallToolResult, error) {
-			fmt.Fprintln(os.Stderr, "method invoked: similarly_used_words")
-			// Extract required parameters using the helper methods
-			datasetID, err := request.RequireString("dataset_id")
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-
-			modelID, err := request.RequireString("model_id")
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-
-			posOrSfn := request.GetString("pos_or_sfn", "")
-
-			word, err := request.RequireString("word")
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-
-			// Extract optional parameters with defaults
-			limit := request.GetInt("limit", 10)
-
-			minScore := request.GetFloat("min_score", 0.5)
+	// closeSearcher releases models and closes databases
+	// (in the self-contained mode) once serving is over
+	closeSearcher := func() {
+		ctxClose, cancel := context.WithTimeout(
+			context.Background(), time.Duration(conf.ShutdownTimeoutSecs)*time.Second)
+		defer cancel()
+		if err := searcher.Close(ctxClose); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close searcher: %s\n", err)
+		}
+	}
 
-			// Call your domain function
-			results, appErr := searcher.SimilarlyUsedWords(ctx, datasetID, modelID, posOrSfn, word, limit, float32(minScore))
-			fmt.Fprintln(os.Stderr, "RESULTS:", results)
-			if !appErr.IsZero() {
-				return mcp.NewToolResultError(fmt.Sprintf("Error: %v", appErr)), nil
-			}
-			var formattedRes strings.Builder
-			for i, res := range results {
-				formattedRes.WriteString(fmt.Sprintf("%d. %s (score %01.2f)\n", i, res.Word, res.Score))
-			}
-			res := mcp.NewToolResultText(formattedRes.String())
-			// Format and return results
-			return res, nil
-		})
+	s := mcp.NewServer(searcher, conf.Corpora, conf.Models)
 
-	// Start the server
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+	switch conf.MCP.Transport {
+	case config.MCPTransportHTTP:
+		authn, err := auth.NewAuthenticator(conf.Auth)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set up authentication: %s\n", err)
+			closeSearcher()
+			os.Exit(1)
+		}
+		transport := mcp.NewHTTPTransport(s, conf.MCP.BasePath, authn)
+		if err := transport.ListenAndServe(ctx, conf.MCP.ListenAddress); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+			closeSearcher()
+			os.Exit(1)
+		}
+	default:
+		if err := server.ServeStdio(s); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		}
 	}
+	closeSearcher()
 }
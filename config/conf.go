@@ -17,9 +17,16 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/netip"
 	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/czcorpus/cnc-gokit/logging"
 	"github.com/czcorpus/wsserver/corpora"
@@ -30,6 +37,49 @@ import (
 const (
 	dfltServerWriteTimeoutSecs = 10
 	dfltServerReadTimeoutSecs  = 10
+	dfltShutdownTimeoutSecs    = 20
+	dfltMCPRequestTimeoutSecs  = 30
+	dfltMCPMaxRetries          = 2
+	dfltMCPRetryBackoffMs      = 200
+	dfltMCPBasePath            = "/mcp"
+	dfltResultCacheMaxEntries  = 5000
+	dfltResultCacheTTLSecs     = 3600
+	dfltHTTPCacheControl       = "public, max-age=300"
+
+	MCPTransportStdio = "stdio"
+	MCPTransportHTTP  = "http"
+
+	// ForwardedHeaderXFF and ForwardedHeaderForwarded name headers
+	// trusted proxies can use to pass client addresses
+	ForwardedHeaderXFF       = "x-forwarded-for"
+	ForwardedHeaderForwarded = "forwarded"
+
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+
+	dfltTracingSampleRatio = 1.0
+
+	// AuthAllDatasets grants access to all the datasets
+	AuthAllDatasets = "*"
+
+	dfltRateLimitRequestsPerSec          = 20
+	dfltRateLimitBurst                   = 40
+	dfltRateLimitExpensiveRequestsPerSec = 2
+	dfltRateLimitExpensiveBurst          = 10
+
+	// CORSAnyOrigin allows cross-origin requests from any origin
+	CORSAnyOrigin = "*"
+
+	dfltCORSMaxAgeSecs = 600
+
+	dfltTLSReloadIntervalSecs = 60
+)
+
+var (
+	dfltCORSAllowedMethods = []string{"GET", "HEAD"}
+	dfltCORSAllowedHeaders = []string{"Authorization", "X-API-Key", "X-Request-ID", "If-None-Match"}
+	dfltCORSExposedHeaders = []string{"ETag", "Retry-After", "X-Request-ID"}
 )
 
 // VersionInfo provides a detailed information about the actual build
@@ -41,6 +91,239 @@ type VersionInfo struct {
 
 type MCPConfig struct {
 	SelfContained bool `json:"selfContained"`
+
+	// ServerURL is a base URL of a wsserver instance the MCP server
+	// forwards queries to in case it is not self-contained
+	ServerURL string `json:"serverUrl"`
+
+	RequestTimeoutSecs int `json:"requestTimeoutSecs"`
+
+	// MaxRetries specifies how many times a failed request to wsserver
+	// is repeated. Zero means default value, negative value disables retries.
+	MaxRetries int `json:"maxRetries"`
+
+	// RetryBackoffMs is an initial delay between retries. With each
+	// retry, the delay doubles.
+	RetryBackoffMs int `json:"retryBackoffMs"`
+
+	// Transport specifies how wssmcp communicates with clients - either
+	// "stdio" (default) or "http" (streamable HTTP along with SSE)
+	Transport string `json:"transport"`
+
+	// ListenAddress is a host:port the wssmcp HTTP transport listens on
+	ListenAddress string `json:"listenAddress"`
+
+	// BasePath is a URL path under which MCP HTTP endpoints are available.
+	// Streamable HTTP uses the path itself, SSE uses [BasePath]/sse and
+	// [BasePath]/message.
+	BasePath string `json:"basePath"`
+
+	// MountInServer makes wsserver serve MCP HTTP endpoints along with
+	// its REST API (i.e. no separate wssmcp process is needed)
+	MountInServer bool `json:"mountInServer"`
+
+	// APIKey is sent to wsserver in case it requires authentication
+	APIKey string `json:"apiKey"`
+
+	// ServerSocket is a path of a Unix domain socket wsserver listens on.
+	// If set, requests are sent via the socket and serverUrl is used only
+	// to create request URLs.
+	ServerSocket string `json:"serverSocket"`
+
+	// ClientTLS configures a client certificate (for wsserver requiring
+	// mTLS) and trusted CAs for an https serverUrl
+	ClientTLS ClientTLSConf `json:"clientTls"`
+}
+
+// ClientTLSConf configures TLS of connections to wsserver
+type ClientTLSConf struct {
+
+	// CertFile and KeyFile specify a client certificate
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// CAFile specifies CA certificates used to verify the server
+	// (in addition to the system ones)
+	CAFile string `json:"caFile"`
+}
+
+// TLSConf configures HTTPS serving. TLS is enabled once
+// the certificate file is configured.
+type TLSConf struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// ClientCAFile enables verification of client certificates (mTLS)
+	// signed by the CAs from the file
+	ClientCAFile string `json:"clientCaFile"`
+
+	// RequireClientCert rejects clients without a valid certificate.
+	// Otherwise, a client certificate is verified only if provided.
+	RequireClientCert bool `json:"requireClientCert"`
+
+	// ReloadIntervalSecs specifies how often the files are checked
+	// for changes (e.g. a renewed certificate)
+	ReloadIntervalSecs int `json:"reloadIntervalSecs"`
+}
+
+// Enabled tells whether the server should use TLS
+func (c TLSConf) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// QueryDeadlines specifies maximum durations of queries per API
+// endpoint. Zero values default to serverWriteTimeoutSecs.
+type QueryDeadlines struct {
+	SimilarWordsSecs int `json:"similarWordsSecs"`
+	CollocationsSecs int `json:"collocationsSecs"`
+	DictionarySecs   int `json:"dictionarySecs"`
+}
+
+// ResultCacheConf configures caching of query results
+// (similar words, collocations, dictionary).
+type ResultCacheConf struct {
+	Disabled   bool `json:"disabled"`
+	MaxEntries int  `json:"maxEntries"`
+
+	// TTLSecs specifies how long a cached result is valid.
+	// Negative value means the entries never expire.
+	TTLSecs int `json:"ttlSecs"`
+}
+
+// HTTPCacheConf configures cache validators (ETag) and
+// the Cache-Control header of query responses.
+type HTTPCacheConf struct {
+	Disabled bool `json:"disabled"`
+
+	// CacheControl is a default Cache-Control value of query responses
+	CacheControl string `json:"cacheControl"`
+
+	// RouteCacheControl overrides CacheControl for specific API
+	// operations (keys are operation IDs as listed in /openapi.json)
+	RouteCacheControl map[string]string `json:"routeCacheControl"`
+}
+
+// MetricsConf configures the Prometheus metrics endpoint
+type MetricsConf struct {
+	Disabled bool `json:"disabled"`
+}
+
+// TracingConf configures OpenTelemetry tracing
+type TracingConf struct {
+
+	// Exporter is one of "none" (default), "otlp" (OTLP over HTTP)
+	// and "stdout" (for testing)
+	Exporter string `json:"exporter"`
+
+	// OTLPEndpoint is a host:port of an OTLP collector. If empty,
+	// standard OTEL_EXPORTER_OTLP_* environment variables apply.
+	OTLPEndpoint string `json:"otlpEndpoint"`
+
+	OTLPInsecure bool `json:"otlpInsecure"`
+
+	// SampleRatio specifies a fraction of sampled traces for requests
+	// without a sampling decision of a caller (0 means default: 1)
+	SampleRatio float64 `json:"sampleRatio"`
+}
+
+// RateLimitRule is a token bucket specification
+type RateLimitRule struct {
+	RequestsPerSec float64 `json:"requestsPerSec"`
+	Burst          int     `json:"burst"`
+}
+
+// ClientRateLimits specifies limits of a single client. Expensive
+// limits apply to similarity and collocation queries.
+type ClientRateLimits struct {
+	Default   RateLimitRule `json:"default"`
+	Expensive RateLimitRule `json:"expensive"`
+}
+
+// RateLimitConf configures rate limiting of API clients. Clients
+// are identified by their API key name (if authenticated) or by their
+// address. The limiting is disabled by default. When enabling it for
+// a server behind a reverse proxy, trustedProxies must be set too,
+// otherwise all the clients of the proxy share a single limit.
+type RateLimitConf struct {
+	Enabled bool `json:"enabled"`
+
+	ClientRateLimits
+
+	// ClientOverrides assigns different limits to specific clients
+	// (keys are client addresses or API key names)
+	ClientOverrides map[string]ClientRateLimits `json:"clientOverrides"`
+
+	// MaxConcurrentScans limits the number of simultaneous expensive
+	// scans (similarity scans of word embeddings models and collocation
+	// queries, including the timed out ones still running in background).
+	// Zero means the number of CPUs.
+	MaxConcurrentScans int `json:"maxConcurrentScans"`
+}
+
+// APIKeyConf describes an API key along with datasets
+// and models the key grants access to
+type APIKeyConf struct {
+
+	// Name identifies the key owner (e.g. in logs)
+	Name string `json:"name"`
+
+	// Key is the key itself. Alternatively, KeySHA256 can be used
+	// so the key is not stored in the configuration.
+	Key string `json:"key"`
+
+	// KeySHA256 is a hex-encoded SHA-256 hash of the key
+	KeySHA256 string `json:"keySha256"`
+
+	// Datasets lists accessible datasets ("*" means all)
+	Datasets []string `json:"datasets"`
+
+	// Models restricts access to models in the form datasetId/modelId.
+	// Datasets without any entry have all their models accessible.
+	Models []string `json:"models"`
+
+	// Admin grants access to service endpoints (e.g. result cache
+	// statistics) which are otherwise unavailable with authentication
+	// enabled
+	Admin bool `json:"admin"`
+}
+
+// AuthConf configures API key authentication
+type AuthConf struct {
+	Enabled bool `json:"enabled"`
+
+	// PublicDatasets are accessible without an API key ("*" means all)
+	PublicDatasets []string `json:"publicDatasets"`
+
+	APIKeys []APIKeyConf `json:"apiKeys"`
+}
+
+// CORSConf configures Cross-Origin Resource Sharing. CORS is enabled
+// once at least one allowed origin is configured.
+type CORSConf struct {
+
+	// AllowedOrigins lists origins (e.g. "https://www.korpus.cz") allowed
+	// to call the API from browsers. A "*." prefix of the host matches
+	// any subdomain (e.g. "https://*.korpus.cz"), a single "*" matches
+	// any origin.
+	AllowedOrigins []string `json:"allowedOrigins"`
+
+	AllowedMethods []string `json:"allowedMethods"`
+
+	// AllowedHeaders lists request headers browsers can send
+	AllowedHeaders []string `json:"allowedHeaders"`
+
+	// ExposedHeaders lists response headers readable by browser scripts
+	ExposedHeaders []string `json:"exposedHeaders"`
+
+	AllowCredentials bool `json:"allowCredentials"`
+
+	// MaxAgeSecs specifies how long browsers can cache preflight responses
+	MaxAgeSecs int `json:"maxAgeSecs"`
+}
+
+// Enabled tells whether CORS headers should be sent
+func (c CORSConf) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
 }
 
 type Config struct {
@@ -48,11 +331,121 @@ type Config struct {
 	ListenPort             int                     `json:"listenPort"`
 	ServerWriteTimeoutSecs int                     `json:"serverWriteTimeoutSecs"`
 	ServerReadTimeoutSecs  int                     `json:"serverReadTimeoutSecs"`
+	ShutdownTimeoutSecs    int                     `json:"shutdownTimeoutSecs"`
 	DataDir                string                  `json:"dataDir"`
+	AllowedDataRoots       []string                `json:"allowedDataRoots"`
 	Models                 []model.ModelConf       `json:"models"`
+	CollDatabases          []model.CollDBConf      `json:"collDatabases"`
 	Corpora                map[string]corpora.Info `json:"corpora"`
 	Logging                logging.LoggingConf     `json:"logging"`
 	MCP                    MCPConfig               `json:"mcp"`
+	QueryDeadlines         QueryDeadlines          `json:"queryDeadlines"`
+	ResultCache            ResultCacheConf         `json:"resultCache"`
+	HTTPCache              HTTPCacheConf           `json:"httpCache"`
+	Metrics                MetricsConf             `json:"metrics"`
+	Tracing                TracingConf             `json:"tracing"`
+	RateLimit              RateLimitConf           `json:"rateLimit"`
+	Auth                   AuthConf                `json:"auth"`
+	CORS                   CORSConf                `json:"cors"`
+	TLS                    TLSConf                 `json:"tls"`
+
+	// EnableH2C enables HTTP/2 over plain TCP (with prior knowledge)
+	EnableH2C bool `json:"enableH2c"`
+
+	// UnixSocket is a path of a Unix domain socket the server listens
+	// on in addition to listenAddress:listenPort. The socket is served
+	// without TLS. Socket peers are trusted proxies so a reverse proxy
+	// connected via the socket should send the forwardedHeader. Otherwise, all the requests of the socket count as a single client
+	// (e.g. for rate limiting).
+	UnixSocket string `json:"unixSocket"`
+
+	// TrustedProxies lists addresses and CIDR ranges of reverse proxies
+	// whose forwardedHeader is trusted when resolving client addresses.
+	// By default, no proxy is trusted except for peers connected via
+	// the Unix socket.
+	TrustedProxies []string `json:"trustedProxies"`
+
+	// ForwardedHeader is the header trusted proxies set to pass client
+	// addresses ("x-forwarded-for" (default) or "forwarded"). Only this
+	// header is read as proxies typically do not remove the other one
+	// so a client could forge it.
+	ForwardedHeader string `json:"forwardedHeader"`
+}
+
+// TrustedProxyPrefixes parses trustedProxies into address prefixes.
+// A plain address is considered a single-address prefix.
+func (conf *Config) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	ans := make([]netip.Prefix, 0, len(conf.TrustedProxies))
+	for i, v := range conf.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(v); err == nil {
+			ans = append(ans, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("trustedProxies[%d]: invalid address or CIDR range '%s'", i, v)
+		}
+		addr = addr.Unmap()
+		ans = append(ans, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return ans, nil
+}
+
+// PathResolver creates a resolver for model and database
+// paths based on the configuration.
+func (conf *Config) PathResolver() *model.PathResolver {
+	return model.NewPathResolver(conf.DataDir, conf.AllowedDataRoots)
+}
+
+// EffectiveCollDatabases returns all the explicitly configured
+// collocation databases along with the ones implicitly defined
+// by the legacy `models[].syntaxDatabasePath` (such databases have
+// their ID equal to the corpus name). Problems found while merging
+// legacy entries (e.g. conflicting paths for a single corpus) are
+// returned as the second value.
+func (conf *Config) EffectiveCollDatabases() ([]model.CollDBConf, []error) {
+	ans := make([]model.CollDBConf, 0, len(conf.CollDatabases)+len(conf.Models))
+	ans = append(ans, conf.CollDatabases...)
+	problems := make([]error, 0, 5)
+	legacy := make(map[string]int)
+	for i, m := range conf.Models {
+		if m.SyntaxDatabasePath == "" {
+			continue
+		}
+		if m.CollDatabaseID != "" {
+			problems = append(
+				problems,
+				fmt.Errorf("models[%d]: collDatabaseId and syntaxDatabasePath cannot be combined", i),
+			)
+			continue
+		}
+		if prev, ok := legacy[m.Corpname]; ok {
+			if conf.Models[prev].SyntaxDatabasePath != m.SyntaxDatabasePath {
+				problems = append(
+					problems,
+					fmt.Errorf(
+						"models[%d]: syntaxDatabasePath conflicts with models[%d] of the same corpus, please use collDatabases instead",
+						i, prev,
+					),
+				)
+			}
+			continue
+		}
+		legacy[m.Corpname] = i
+		if slices.ContainsFunc(conf.CollDatabases, func(v model.CollDBConf) bool { return v.ID == m.Corpname }) {
+			problems = append(
+				problems,
+				fmt.Errorf("models[%d]: legacy syntaxDatabasePath conflicts with collDatabases entry '%s'", i, m.Corpname),
+			)
+			continue
+		}
+		ans = append(ans, model.CollDBConf{
+			ID:       m.Corpname,
+			Corpname: m.Corpname,
+			Path:     m.SyntaxDatabasePath,
+		})
+	}
+	return ans, problems
 }
 
 func ApplyDefaults(conf *Config) {
@@ -70,9 +463,209 @@ func ApplyDefaults(conf *Config) {
 			dfltServerReadTimeoutSecs,
 		)
 	}
+	if conf.ShutdownTimeoutSecs == 0 {
+		conf.ShutdownTimeoutSecs = dfltShutdownTimeoutSecs
+		log.Warn().Msgf(
+			"shutdownTimeoutSecs not specified, using default: %d",
+			dfltShutdownTimeoutSecs,
+		)
+	}
+	deadlines := []*int{
+		&conf.QueryDeadlines.SimilarWordsSecs,
+		&conf.QueryDeadlines.CollocationsSecs,
+		&conf.QueryDeadlines.DictionarySecs,
+	}
+	var dfltDeadlineUsed bool
+	for _, d := range deadlines {
+		if *d == 0 {
+			*d = conf.ServerWriteTimeoutSecs
+			dfltDeadlineUsed = true
+		}
+	}
+	if dfltDeadlineUsed {
+		log.Warn().Msgf(
+			"some queryDeadlines not specified, using serverWriteTimeoutSecs: %d",
+			conf.ServerWriteTimeoutSecs,
+		)
+	}
+	if !conf.ResultCache.Disabled {
+		if conf.ResultCache.MaxEntries == 0 {
+			conf.ResultCache.MaxEntries = dfltResultCacheMaxEntries
+			log.Warn().Msgf(
+				"resultCache.maxEntries not specified, using default: %d",
+				dfltResultCacheMaxEntries,
+			)
+		}
+		if conf.ResultCache.TTLSecs == 0 {
+			conf.ResultCache.TTLSecs = dfltResultCacheTTLSecs
+			log.Warn().Msgf(
+				"resultCache.ttlSecs not specified, using default: %d",
+				dfltResultCacheTTLSecs,
+			)
+		}
+	}
+	if !conf.HTTPCache.Disabled && conf.HTTPCache.CacheControl == "" {
+		conf.HTTPCache.CacheControl = dfltHTTPCacheControl
+		log.Warn().Msgf(
+			"httpCache.cacheControl not specified, using default: %s",
+			dfltHTTPCacheControl,
+		)
+	}
+	if conf.Tracing.Exporter == "" {
+		conf.Tracing.Exporter = TracingExporterNone
+	}
+	if conf.Tracing.Exporter != TracingExporterNone && conf.Tracing.SampleRatio == 0 {
+		conf.Tracing.SampleRatio = dfltTracingSampleRatio
+		log.Warn().Msgf(
+			"tracing.sampleRatio not specified, using default: %v",
+			dfltTracingSampleRatio,
+		)
+	}
+	if conf.RateLimit.Enabled {
+		applyRateLimitDefaults(&conf.RateLimit.ClientRateLimits)
+		if len(conf.TrustedProxies) == 0 && conf.UnixSocket == "" {
+			log.Warn().Msg(
+				"rateLimit enabled without trustedProxies, clients behind a reverse proxy will share one limit")
+		}
+	}
+	if conf.ForwardedHeader == "" {
+		conf.ForwardedHeader = ForwardedHeaderXFF
+		if len(conf.TrustedProxies) > 0 || conf.UnixSocket != "" {
+			log.Warn().Msgf("forwardedHeader not specified, using default: %s", ForwardedHeaderXFF)
+		}
+	}
+	if conf.RateLimit.MaxConcurrentScans == 0 {
+		conf.RateLimit.MaxConcurrentScans = runtime.NumCPU()
+		log.Warn().Msgf(
+			"rateLimit.maxConcurrentScans not specified, using number of CPUs: %d",
+			conf.RateLimit.MaxConcurrentScans,
+		)
+	}
+	if conf.CORS.Enabled() {
+		applyCORSDefaults(&conf.CORS)
+	}
+	if conf.TLS.Enabled() && conf.TLS.ReloadIntervalSecs == 0 {
+		conf.TLS.ReloadIntervalSecs = dfltTLSReloadIntervalSecs
+		log.Warn().Msgf(
+			"tls.reloadIntervalSecs not specified, using default: %d",
+			dfltTLSReloadIntervalSecs,
+		)
+	}
+	if conf.MCP.Transport == "" {
+		conf.MCP.Transport = MCPTransportStdio
+	}
+	if conf.MCP.BasePath == "" {
+		conf.MCP.BasePath = dfltMCPBasePath
+	}
+	if !conf.MCP.SelfContained {
+		if conf.MCP.RequestTimeoutSecs == 0 {
+			conf.MCP.RequestTimeoutSecs = dfltMCPRequestTimeoutSecs
+			log.Warn().Msgf(
+				"mcp.requestTimeoutSecs not specified, using default: %d",
+				dfltMCPRequestTimeoutSecs,
+			)
+		}
+		if conf.MCP.MaxRetries == 0 {
+			conf.MCP.MaxRetries = dfltMCPMaxRetries
+			log.Warn().Msgf(
+				"mcp.maxRetries not specified, using default: %d",
+				dfltMCPMaxRetries,
+			)
+		}
+		if conf.MCP.RetryBackoffMs == 0 {
+			conf.MCP.RetryBackoffMs = dfltMCPRetryBackoffMs
+			log.Warn().Msgf(
+				"mcp.retryBackoffMs not specified, using default: %d",
+				dfltMCPRetryBackoffMs,
+			)
+		}
+	}
 
 }
 
+func applyRateLimitDefaults(limits *ClientRateLimits) {
+	rules := []struct {
+		name       string
+		rule       *RateLimitRule
+		dfltPerSec float64
+		dfltBurst  int
+	}{
+		{
+			"default", &limits.Default,
+			dfltRateLimitRequestsPerSec, dfltRateLimitBurst,
+		},
+		{
+			"expensive", &limits.Expensive,
+			dfltRateLimitExpensiveRequestsPerSec, dfltRateLimitExpensiveBurst,
+		},
+	}
+	for _, r := range rules {
+		if r.rule.RequestsPerSec == 0 {
+			r.rule.RequestsPerSec = r.dfltPerSec
+			log.Warn().Msgf(
+				"rateLimit.%s.requestsPerSec not specified, using default: %v",
+				r.name, r.dfltPerSec,
+			)
+		}
+		if r.rule.Burst == 0 {
+			r.rule.Burst = r.dfltBurst
+			log.Warn().Msgf(
+				"rateLimit.%s.burst not specified, using default: %d",
+				r.name, r.dfltBurst,
+			)
+		}
+	}
+}
+
+func applyCORSDefaults(cors *CORSConf) {
+	if len(cors.AllowedMethods) == 0 {
+		cors.AllowedMethods = dfltCORSAllowedMethods
+		log.Warn().Msgf(
+			"cors.allowedMethods not specified, using default: %s",
+			strings.Join(dfltCORSAllowedMethods, ", "),
+		)
+	}
+	if len(cors.AllowedHeaders) == 0 {
+		cors.AllowedHeaders = dfltCORSAllowedHeaders
+		log.Warn().Msgf(
+			"cors.allowedHeaders not specified, using default: %s",
+			strings.Join(dfltCORSAllowedHeaders, ", "),
+		)
+	}
+	if len(cors.ExposedHeaders) == 0 {
+		cors.ExposedHeaders = dfltCORSExposedHeaders
+		log.Warn().Msgf(
+			"cors.exposedHeaders not specified, using default: %s",
+			strings.Join(dfltCORSExposedHeaders, ", "),
+		)
+	}
+	if cors.MaxAgeSecs == 0 {
+		cors.MaxAgeSecs = dfltCORSMaxAgeSecs
+		log.Warn().Msgf(
+			"cors.maxAgeSecs not specified, using default: %d",
+			dfltCORSMaxAgeSecs,
+		)
+	}
+}
+
+// offsetToLineCol converts a byte offset within data into
+// a 1-based line and column
+func offsetToLineCol(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	preceding := data[:offset]
+	line := bytes.Count(preceding, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(preceding, '\n')
+	return line, col
+}
+
+// Load reads a JSON configuration from a specified path.
+// Before parsing, all the ${VAR} expressions are replaced
+// by respective environment variables values.
+// The loading is strict - unknown fields and invalid value
+// types are reported as errors along with their position
+// in the (interpolated) file.
 func Load(path string) (*Config, error) {
 	if path == "" {
 		return nil, fmt.Errorf("Config path not specified")
@@ -81,7 +674,43 @@ func Load(path string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	rawData, err = interpolateEnv(rawData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process config file %s: %w", path, err)
+	}
 	var conf Config
-	json.Unmarshal(rawData, &conf)
+	dec := json.NewDecoder(bytes.NewReader(rawData))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&conf); err != nil {
+		offset := dec.InputOffset()
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) {
+			// the offset follows the offending character
+			offset = max(0, syntaxErr.Offset-1)
+
+		} else if errors.As(err, &typeErr) {
+			offset = typeErr.Offset
+
+		} else if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			// the decoder reports the end of the top-level value here,
+			// so we try to locate the field key itself (the quoted name
+			// followed by a colon cannot be a string value)
+			keyRx := regexp.MustCompile(regexp.QuoteMeta(field) + `\s*:`)
+			if loc := keyRx.FindIndex(rawData); loc != nil {
+				offset = int64(loc[0])
+			}
+		}
+		line, col := offsetToLineCol(rawData, offset)
+		return nil, fmt.Errorf(
+			"failed to parse config file %s (line %d, column %d): %w", path, line, col, err)
+	}
+	if dec.More() {
+		line, col := offsetToLineCol(rawData, dec.InputOffset())
+		return nil, fmt.Errorf(
+			"failed to parse config file %s (line %d, column %d): unexpected data after the top-level object",
+			path, line, col,
+		)
+	}
 	return &conf, nil
 }
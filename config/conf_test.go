@@ -0,0 +1,119 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConf(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "conf.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOffsetToLineCol(t *testing.T) {
+	data := []byte("{\n  \"a\": 1,\n  \"b\": 2\n}")
+	tests := []struct {
+		offset   int64
+		wantLine int
+		wantCol  int
+	}{
+		{0, 1, 1},
+		{2, 2, 1},
+		{4, 2, 3},
+		{14, 3, 3},
+		{1000, 4, 2},
+	}
+	for _, tt := range tests {
+		line, col := offsetToLineCol(data, tt.offset)
+		assert.Equal(t, tt.wantLine, line, tt.offset)
+		assert.Equal(t, tt.wantCol, col, tt.offset)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("WSS_TEST_PORT_DESC", "test server")
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid config",
+			data: "{\n  \"listenPort\": 8080,\n  \"dataDir\": \"/data\"\n}",
+		},
+		{
+			name: "interpolated config",
+			data: `{"dataDir": "/data/${WSS_TEST_PORT_DESC}"}`,
+		},
+		{
+			name:    "syntax error",
+			data:    "{\n  \"listenPort\": 8080,\n  \"dataDir\": \"/data\",\n}",
+			wantErr: "(line 4, column 1)",
+		},
+		{
+			name:    "unknown field",
+			data:    "{\n  \"listenPort\": 8080,\n  \"listenPortt\": 8081\n}",
+			wantErr: "(line 3, column 3)",
+		},
+		{
+			name:    "unknown field named like an earlier value",
+			data:    "{\n  \"dataDir\": \"listenPortt\",\n  \"unixSocket\": \"\\\"listenPortt\\\": x\",\n  \"listenPortt\" : 8081\n}",
+			wantErr: "(line 4, column 3)",
+		},
+		{
+			name:    "invalid value type",
+			data:    "{\n  \"listenPort\": \"8080\"\n}",
+			wantErr: "(line 2, column",
+		},
+		{
+			name:    "data after the top-level object",
+			data:    "{\"listenPort\": 8080}\n{}",
+			wantErr: "unexpected data after the top-level object",
+		},
+		{
+			name:    "undefined variable",
+			data:    `{"dataDir": "${WSS_TEST_UNDEFINED}"}`,
+			wantErr: "undefined environment variable(s): WSS_TEST_UNDEFINED",
+		},
+	}
+	for _, tt := range tests {
+		conf, err := Load(writeConf(t, tt.data))
+		if tt.wantErr != "" {
+			if assert.Error(t, err, tt.name) {
+				assert.Contains(t, err.Error(), tt.wantErr, tt.name)
+			}
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.NotNil(t, conf, tt.name)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+	_, err = Load("")
+	assert.Error(t, err)
+}
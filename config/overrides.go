@@ -0,0 +1,246 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/logging"
+)
+
+const (
+	EnvListenAddress    = "WSSERVER_LISTEN_ADDRESS"
+	EnvListenPort       = "WSSERVER_LISTEN_PORT"
+	EnvDataDir          = "WSSERVER_DATA_DIR"
+	EnvReadTimeoutSecs  = "WSSERVER_SERVER_READ_TIMEOUT_SECS"
+	EnvWriteTimeoutSecs = "WSSERVER_SERVER_WRITE_TIMEOUT_SECS"
+	EnvLogLevel         = "WSSERVER_LOG_LEVEL"
+	EnvMCPSelfContained = "WSSERVER_MCP_SELF_CONTAINED"
+	EnvMCPServerURL     = "WSSERVER_MCP_SERVER_URL"
+	EnvMCPTransport     = "WSSERVER_MCP_TRANSPORT"
+	EnvMCPListenAddress = "WSSERVER_MCP_LISTEN_ADDRESS"
+
+	redactedValue = "*****"
+)
+
+var (
+	interpolationRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*(:-[^}]*)?)\}`)
+	secretKeyRegexp     = regexp.MustCompile(`(?i)(secret|password|passwd|token|apikey|api_key|credential|^key$)`)
+)
+
+// interpolateEnv replaces all the ${VAR} and ${VAR:-default} occurrences
+// in raw JSON data with values of respective environment variables.
+// Values are JSON-escaped so they can be safely used inside strings.
+// Undefined variables without a default value are reported as an error.
+func interpolateEnv(data []byte) ([]byte, error) {
+	var missing []string
+	ans := interpolationRegexp.ReplaceAllFunc(data, func(m []byte) []byte {
+		expr := string(interpolationRegexp.FindSubmatch(m)[1])
+		name, dflt, hasDflt := strings.Cut(expr, ":-")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if !hasDflt {
+				missing = append(missing, name)
+				return m
+			}
+			value = dflt
+		}
+		escaped, _ := json.Marshal(value)
+		return escaped[1 : len(escaped)-1]
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined environment variable(s): %s", strings.Join(missing, ", "))
+	}
+	return ans, nil
+}
+
+// ApplyEnvOverrides sets configuration values from WSSERVER_*
+// environment variables (if defined).
+func ApplyEnvOverrides(conf *Config) error {
+	if v, ok := os.LookupEnv(EnvListenAddress); ok {
+		conf.ListenAddress = v
+	}
+	if v, ok := os.LookupEnv(EnvListenPort); ok {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %w", EnvListenPort, err)
+		}
+		conf.ListenPort = port
+	}
+	if v, ok := os.LookupEnv(EnvDataDir); ok {
+		conf.DataDir = v
+	}
+	if v, ok := os.LookupEnv(EnvReadTimeoutSecs); ok {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %w", EnvReadTimeoutSecs, err)
+		}
+		conf.ServerReadTimeoutSecs = secs
+	}
+	if v, ok := os.LookupEnv(EnvWriteTimeoutSecs); ok {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %w", EnvWriteTimeoutSecs, err)
+		}
+		conf.ServerWriteTimeoutSecs = secs
+	}
+	if v, ok := os.LookupEnv(EnvLogLevel); ok {
+		conf.Logging.Level = logging.LogLevel(v)
+	}
+	if v, ok := os.LookupEnv(EnvMCPSelfContained); ok {
+		selfContained, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %w", EnvMCPSelfContained, err)
+		}
+		conf.MCP.SelfContained = selfContained
+	}
+	if v, ok := os.LookupEnv(EnvMCPServerURL); ok {
+		conf.MCP.ServerURL = v
+	}
+	if v, ok := os.LookupEnv(EnvMCPTransport); ok {
+		conf.MCP.Transport = v
+	}
+	if v, ok := os.LookupEnv(EnvMCPListenAddress); ok {
+		conf.MCP.ListenAddress = v
+	}
+	return nil
+}
+
+// CLIOverrides holds configuration values specified via command
+// line flags. Only flags explicitly set by a user are applied.
+type CLIOverrides struct {
+	fs               *flag.FlagSet
+	listenAddress    string
+	listenPort       int
+	dataDir          string
+	readTimeoutSecs  int
+	writeTimeoutSecs int
+	logLevel         string
+	mcpSelfContained bool
+	mcpServerURL     string
+	mcpTransport     string
+	mcpListenAddress string
+}
+
+// Apply sets all the explicitly specified flag values to conf.
+// The method must be called after the flag set is parsed.
+func (ovr *CLIOverrides) Apply(conf *Config) {
+	ovr.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen-address":
+			conf.ListenAddress = ovr.listenAddress
+		case "listen-port":
+			conf.ListenPort = ovr.listenPort
+		case "data-dir":
+			conf.DataDir = ovr.dataDir
+		case "read-timeout":
+			conf.ServerReadTimeoutSecs = ovr.readTimeoutSecs
+		case "write-timeout":
+			conf.ServerWriteTimeoutSecs = ovr.writeTimeoutSecs
+		case "log-level":
+			conf.Logging.Level = logging.LogLevel(ovr.logLevel)
+		case "mcp-self-contained":
+			conf.MCP.SelfContained = ovr.mcpSelfContained
+		case "mcp-server-url":
+			conf.MCP.ServerURL = ovr.mcpServerURL
+		case "mcp-transport":
+			conf.MCP.Transport = ovr.mcpTransport
+		case "mcp-listen-address":
+			conf.MCP.ListenAddress = ovr.mcpListenAddress
+		}
+	})
+}
+
+// RegisterOverrideFlags defines configuration override flags
+// on a provided flag set.
+func RegisterOverrideFlags(fs *flag.FlagSet) *CLIOverrides {
+	ans := &CLIOverrides{fs: fs}
+	fs.StringVar(&ans.listenAddress, "listen-address", "", "override listenAddress (env: "+EnvListenAddress+")")
+	fs.IntVar(&ans.listenPort, "listen-port", 0, "override listenPort (env: "+EnvListenPort+")")
+	fs.StringVar(&ans.dataDir, "data-dir", "", "override dataDir (env: "+EnvDataDir+")")
+	fs.IntVar(&ans.readTimeoutSecs, "read-timeout", 0, "override serverReadTimeoutSecs (env: "+EnvReadTimeoutSecs+")")
+	fs.IntVar(&ans.writeTimeoutSecs, "write-timeout", 0, "override serverWriteTimeoutSecs (env: "+EnvWriteTimeoutSecs+")")
+	fs.StringVar(&ans.logLevel, "log-level", "", "override logging.level (env: "+EnvLogLevel+")")
+	fs.BoolVar(&ans.mcpSelfContained, "mcp-self-contained", false, "override mcp.selfContained (env: "+EnvMCPSelfContained+")")
+	fs.StringVar(&ans.mcpServerURL, "mcp-server-url", "", "override mcp.serverUrl (env: "+EnvMCPServerURL+")")
+	fs.StringVar(&ans.mcpTransport, "mcp-transport", "", "override mcp.transport (env: "+EnvMCPTransport+")")
+	fs.StringVar(&ans.mcpListenAddress, "mcp-listen-address", "", "override mcp.listenAddress (env: "+EnvMCPListenAddress+")")
+	return ans
+}
+
+// LoadEffective loads the configuration file and applies environment
+// and command line overrides. The values are resolved with the following
+// precedence (the first one found wins):
+//
+//  1. command line flags (e.g. -listen-port 8080)
+//  2. environment variables (e.g. WSSERVER_LISTEN_PORT=8080)
+//  3. the JSON configuration file (including ${VAR} interpolation)
+//  4. built-in defaults (see ApplyDefaults)
+//
+// The cli argument may be nil.
+func LoadEffective(path string, cli *CLIOverrides) (*Config, error) {
+	conf, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyEnvOverrides(conf); err != nil {
+		return nil, err
+	}
+	if cli != nil {
+		cli.Apply(conf)
+	}
+	return conf, nil
+}
+
+func redactSecrets(data any) any {
+	switch tData := data.(type) {
+	case map[string]any:
+		for k, v := range tData {
+			if s, ok := v.(string); ok && s != "" && secretKeyRegexp.MatchString(k) {
+				tData[k] = redactedValue
+
+			} else {
+				tData[k] = redactSecrets(v)
+			}
+		}
+	case []any:
+		for i, v := range tData {
+			tData[i] = redactSecrets(v)
+		}
+	}
+	return data
+}
+
+// PrintableJSON encodes the configuration into an indented JSON
+// with all the secret-looking values (passwords, tokens, keys) redacted.
+func PrintableJSON(conf *Config) ([]byte, error) {
+	raw, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var data any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactSecrets(data), "", "  ")
+}
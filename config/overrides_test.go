@@ -0,0 +1,123 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	t.Setenv("WSS_TEST_DIR", "/data/wss")
+	t.Setenv("WSS_TEST_QUOTED", `a "quoted" \ value`)
+	t.Setenv("WSS_TEST_EMPTY", "")
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "no variables",
+			input: `{"dataDir": "/data"}`,
+			want:  `{"dataDir": "/data"}`,
+		},
+		{
+			name:  "defined variable",
+			input: `{"dataDir": "${WSS_TEST_DIR}/models"}`,
+			want:  `{"dataDir": "/data/wss/models"}`,
+		},
+		{
+			name:  "value is JSON-escaped",
+			input: `{"description": "${WSS_TEST_QUOTED}"}`,
+			want:  `{"description": "a \"quoted\" \\ value"}`,
+		},
+		{
+			name:  "default of an undefined variable",
+			input: `{"listenAddress": "${WSS_TEST_UNDEFINED:-localhost}"}`,
+			want:  `{"listenAddress": "localhost"}`,
+		},
+		{
+			name:  "empty default",
+			input: `{"listenAddress": "${WSS_TEST_UNDEFINED:-}"}`,
+			want:  `{"listenAddress": ""}`,
+		},
+		{
+			name:  "defined empty variable wins over default",
+			input: `{"listenAddress": "${WSS_TEST_EMPTY:-localhost}"}`,
+			want:  `{"listenAddress": ""}`,
+		},
+		{
+			name:  "not a variable reference",
+			input: `{"description": "$WSS_TEST_DIR and ${1abc}"}`,
+			want:  `{"description": "$WSS_TEST_DIR and ${1abc}"}`,
+		},
+		{
+			name:    "undefined variable",
+			input:   `{"dataDir": "${WSS_TEST_UNDEFINED}"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		ans, err := interpolateEnv([]byte(tt.input))
+		if tt.wantErr {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, string(ans), tt.name)
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv(EnvListenPort, "9090")
+	t.Setenv(EnvMCPSelfContained, "true")
+	t.Setenv(EnvMCPTransport, MCPTransportHTTP)
+	t.Setenv(EnvMCPListenAddress, "127.0.0.1:9091")
+	conf := &Config{ListenPort: 8080, ListenAddress: "localhost"}
+	assert.NoError(t, ApplyEnvOverrides(conf))
+	assert.Equal(t, 9090, conf.ListenPort)
+	assert.Equal(t, "localhost", conf.ListenAddress)
+	assert.True(t, conf.MCP.SelfContained)
+	assert.Equal(t, MCPTransportHTTP, conf.MCP.Transport)
+	assert.Equal(t, "127.0.0.1:9091", conf.MCP.ListenAddress)
+}
+
+func TestApplyEnvOverridesInvalidValue(t *testing.T) {
+	t.Setenv(EnvListenPort, "eighty")
+	assert.Error(t, ApplyEnvOverrides(&Config{}))
+}
+
+func TestCLIOverrides(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cli := RegisterOverrideFlags(fs)
+	err := fs.Parse([]string{
+		"-listen-port", "9090",
+		"-mcp-transport", MCPTransportHTTP,
+		"-mcp-listen-address", ":9091",
+	})
+	assert.NoError(t, err)
+	conf := &Config{ListenPort: 8080, DataDir: "/data"}
+	conf.MCP.Transport = MCPTransportStdio
+	cli.Apply(conf)
+	assert.Equal(t, 9090, conf.ListenPort)
+	assert.Equal(t, "/data", conf.DataDir)
+	assert.Equal(t, MCPTransportHTTP, conf.MCP.Transport)
+	assert.Equal(t, ":9091", conf.MCP.ListenAddress)
+}
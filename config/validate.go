@@ -0,0 +1,313 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/cnc-gokit/fs"
+	"github.com/czcorpus/wsserver/model"
+)
+
+// Validate performs a semantic check of a loaded configuration.
+// Instead of stopping at the first problem, all the found problems
+// are returned so a user can fix them at once.
+func Validate(conf *Config) []error {
+	ans := make([]error, 0, 10)
+	if conf.ListenPort <= 0 || conf.ListenPort > 65535 {
+		ans = append(ans, fmt.Errorf("invalid listenPort: %d", conf.ListenPort))
+	}
+	if !conf.Logging.Level.IsValid() {
+		ans = append(ans, fmt.Errorf("invalid logging.level: '%s'", conf.Logging.Level))
+	}
+	if isDir, err := fs.IsDir(conf.DataDir); err != nil || !isDir {
+		ans = append(ans, fmt.Errorf("dataDir '%s' is not an accessible directory", conf.DataDir))
+	}
+	for i, root := range conf.AllowedDataRoots {
+		if !filepath.IsAbs(root) {
+			ans = append(ans, fmt.Errorf("allowedDataRoots[%d]: path '%s' is not absolute", i, root))
+		}
+	}
+	if _, err := conf.TrustedProxyPrefixes(); err != nil {
+		ans = append(ans, err)
+	}
+	switch conf.ForwardedHeader {
+	case "", ForwardedHeaderXFF, ForwardedHeaderForwarded:
+	default:
+		ans = append(ans, fmt.Errorf("invalid forwardedHeader: '%s'", conf.ForwardedHeader))
+	}
+	switch conf.MCP.Transport {
+	case "", MCPTransportStdio:
+	case MCPTransportHTTP:
+		if conf.MCP.ListenAddress == "" {
+			ans = append(ans, fmt.Errorf("mcp.listenAddress must be set for the http transport"))
+		}
+	default:
+		ans = append(ans, fmt.Errorf("invalid mcp.transport: '%s'", conf.MCP.Transport))
+	}
+	if conf.MCP.BasePath != "" && !strings.HasPrefix(conf.MCP.BasePath, "/") {
+		ans = append(ans, fmt.Errorf("mcp.basePath must start with '/'"))
+	}
+	if conf.ShutdownTimeoutSecs < 0 {
+		ans = append(ans, fmt.Errorf("shutdownTimeoutSecs must not be negative"))
+	}
+	if conf.QueryDeadlines.SimilarWordsSecs < 0 ||
+		conf.QueryDeadlines.CollocationsSecs < 0 ||
+		conf.QueryDeadlines.DictionarySecs < 0 {
+		ans = append(ans, fmt.Errorf("queryDeadlines values must not be negative"))
+	}
+	if conf.ResultCache.MaxEntries < 0 {
+		ans = append(ans, fmt.Errorf("resultCache.maxEntries must not be negative"))
+	}
+	switch conf.Tracing.Exporter {
+	case "", TracingExporterNone, TracingExporterOTLP, TracingExporterStdout:
+	default:
+		ans = append(ans, fmt.Errorf("invalid tracing.exporter: '%s'", conf.Tracing.Exporter))
+	}
+	if conf.Tracing.SampleRatio < 0 || conf.Tracing.SampleRatio > 1 {
+		ans = append(ans, fmt.Errorf("tracing.sampleRatio must be between 0 and 1"))
+	}
+	ans = append(ans, validateRateLimits("rateLimit", conf.RateLimit.ClientRateLimits)...)
+	for client, limits := range conf.RateLimit.ClientOverrides {
+		ans = append(ans, validateRateLimits(fmt.Sprintf("rateLimit.clientOverrides[%s]", client), limits)...)
+	}
+	if conf.RateLimit.MaxConcurrentScans < 0 {
+		ans = append(ans, fmt.Errorf("rateLimit.maxConcurrentScans must not be negative"))
+	}
+	ans = append(ans, validateAuth(conf)...)
+	ans = append(ans, validateCORS(conf.CORS)...)
+	ans = append(ans, validateTLS(conf)...)
+	paths := conf.PathResolver()
+	if len(conf.Models) == 0 {
+		ans = append(ans, fmt.Errorf("no models configured"))
+	}
+	usedKeys := make(map[string]int)
+	for i, m := range conf.Models {
+		if m.Corpname == "" {
+			ans = append(ans, fmt.Errorf("models[%d]: missing corpname", i))
+		}
+		if m.ID == "" {
+			ans = append(ans, fmt.Errorf("models[%d]: missing id", i))
+		}
+		if prev, ok := usedKeys[m.ModelKey()]; ok {
+			ans = append(
+				ans,
+				fmt.Errorf("models[%d]: duplicate model key '%s' (already used by models[%d])", i, m.ModelKey(), prev),
+			)
+
+		} else {
+			usedKeys[m.ModelKey()] = i
+		}
+		if m.Filename == "" {
+			ans = append(ans, fmt.Errorf("models[%d]: missing filename", i))
+
+		} else if modelPath, err := paths.ModelPath(&m); err != nil {
+			ans = append(ans, fmt.Errorf("models[%d]: invalid filename: %w", i, err))
+
+		} else if isFile, err := fs.IsFile(modelPath); err != nil || !isFile {
+			ans = append(ans, fmt.Errorf("models[%d]: model file '%s' not found", i, modelPath))
+		}
+		if _, ok := conf.Corpora[m.Corpname]; !ok && m.Corpname != "" {
+			ans = append(ans, fmt.Errorf("models[%d]: corpus '%s' not described in corpora", i, m.Corpname))
+		}
+	}
+	collDBs, problems := conf.EffectiveCollDatabases()
+	ans = append(ans, problems...)
+	ans = append(ans, validateCollDBs(conf, paths, collDBs)...)
+	return ans
+}
+
+func validateAuth(conf *Config) []error {
+	ans := make([]error, 0, 5)
+	if !conf.Auth.Enabled {
+		return ans
+	}
+	knownDataset := func(datasetID string) bool {
+		if _, ok := conf.Corpora[datasetID]; ok {
+			return true
+		}
+		return slices.ContainsFunc(conf.Models, func(m model.ModelConf) bool { return m.Corpname == datasetID })
+	}
+	checkDatasets := func(prefix string, datasets []string) {
+		for _, d := range datasets {
+			if d != AuthAllDatasets && !knownDataset(d) {
+				ans = append(ans, fmt.Errorf("%s: unknown dataset '%s'", prefix, d))
+			}
+		}
+	}
+	checkDatasets("auth.publicDatasets", conf.Auth.PublicDatasets)
+	names := make(map[string]bool)
+	for i, kc := range conf.Auth.APIKeys {
+		prefix := fmt.Sprintf("auth.apiKeys[%d]", i)
+		if kc.Name == "" {
+			ans = append(ans, fmt.Errorf("%s: missing name", prefix))
+
+		} else if names[kc.Name] {
+			ans = append(ans, fmt.Errorf("%s: duplicate name '%s'", prefix, kc.Name))
+		}
+		names[kc.Name] = true
+		if (kc.Key == "") == (kc.KeySHA256 == "") {
+			ans = append(ans, fmt.Errorf("%s: exactly one of key and keySha256 must be set", prefix))
+
+		} else if kc.KeySHA256 != "" {
+			if hash, err := hex.DecodeString(kc.KeySHA256); err != nil || len(hash) != sha256.Size {
+				ans = append(ans, fmt.Errorf("%s: keySha256 must be a hex-encoded SHA-256 hash", prefix))
+			}
+		}
+		checkDatasets(prefix+".datasets", kc.Datasets)
+		for _, m := range kc.Models {
+			datasetID, modelID, ok := strings.Cut(m, "/")
+			if !ok || !slices.ContainsFunc(
+				conf.Models,
+				func(mc model.ModelConf) bool { return mc.Corpname == datasetID && mc.ID == modelID },
+			) {
+				ans = append(ans, fmt.Errorf("%s.models: unknown model '%s' (expected datasetId/modelId)", prefix, m))
+			}
+		}
+	}
+	return ans
+}
+
+func validateTLS(conf *Config) []error {
+	ans := make([]error, 0, 3)
+	checkFile := func(name, path string) {
+		if isFile, err := fs.IsFile(path); err != nil || !isFile {
+			ans = append(ans, fmt.Errorf("%s: file '%s' not found", name, path))
+		}
+	}
+	if conf.TLS.Enabled() {
+		checkFile("tls.certFile", conf.TLS.CertFile)
+		if conf.TLS.KeyFile == "" {
+			ans = append(ans, fmt.Errorf("tls.keyFile must be set along with tls.certFile"))
+
+		} else {
+			checkFile("tls.keyFile", conf.TLS.KeyFile)
+		}
+		if conf.TLS.ClientCAFile != "" {
+			checkFile("tls.clientCaFile", conf.TLS.ClientCAFile)
+
+		} else if conf.TLS.RequireClientCert {
+			ans = append(ans, fmt.Errorf("tls.requireClientCert requires tls.clientCaFile"))
+		}
+
+	} else if conf.TLS.KeyFile != "" || conf.TLS.ClientCAFile != "" {
+		ans = append(ans, fmt.Errorf("tls.certFile must be set to enable TLS"))
+	}
+	if conf.TLS.ReloadIntervalSecs < 0 {
+		ans = append(ans, fmt.Errorf("tls.reloadIntervalSecs must not be negative"))
+	}
+	if conf.UnixSocket != "" && !filepath.IsAbs(conf.UnixSocket) {
+		ans = append(ans, fmt.Errorf("unixSocket: path '%s' is not absolute", conf.UnixSocket))
+	}
+	clientTLS := conf.MCP.ClientTLS
+	if (clientTLS.CertFile == "") != (clientTLS.KeyFile == "") {
+		ans = append(ans, fmt.Errorf("mcp.clientTls.certFile and mcp.clientTls.keyFile must be set together"))
+	}
+	return ans
+}
+
+func validateCORS(cors CORSConf) []error {
+	ans := make([]error, 0, 3)
+	for i, origin := range cors.AllowedOrigins {
+		if origin == CORSAnyOrigin {
+			if cors.AllowCredentials {
+				ans = append(ans, fmt.Errorf("cors.allowedOrigins[%d]: '*' cannot be combined with allowCredentials", i))
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || strings.Contains(strings.TrimPrefix(u.Host, "*."), "*") {
+			ans = append(
+				ans,
+				fmt.Errorf("cors.allowedOrigins[%d]: invalid origin '%s' (expected scheme://host[:port])", i, origin),
+			)
+		}
+	}
+	if cors.MaxAgeSecs < 0 {
+		ans = append(ans, fmt.Errorf("cors.maxAgeSecs must not be negative"))
+	}
+	return ans
+}
+
+func validateRateLimits(prefix string, limits ClientRateLimits) []error {
+	ans := make([]error, 0, 2)
+	if limits.Default.RequestsPerSec < 0 || limits.Default.Burst < 0 {
+		ans = append(ans, fmt.Errorf("%s.default: values must not be negative", prefix))
+	}
+	if limits.Expensive.RequestsPerSec < 0 || limits.Expensive.Burst < 0 {
+		ans = append(ans, fmt.Errorf("%s.expensive: values must not be negative", prefix))
+	}
+	return ans
+}
+
+func validateCollDBs(conf *Config, paths *model.PathResolver, collDBs []model.CollDBConf) []error {
+	ans := make([]error, 0, 5)
+	dbCorpora := make(map[string]string)
+	for _, db := range collDBs {
+		if db.ID == "" {
+			ans = append(ans, fmt.Errorf("collDatabases: missing id (corpus '%s')", db.Corpname))
+			continue
+		}
+		if _, ok := dbCorpora[db.ID]; ok {
+			ans = append(ans, fmt.Errorf("collDatabases: duplicate id '%s'", db.ID))
+			continue
+		}
+		dbCorpora[db.ID] = db.Corpname
+		if db.Corpname == "" {
+			ans = append(ans, fmt.Errorf("collDatabases '%s': missing corpname", db.ID))
+
+		} else if _, ok := conf.Corpora[db.Corpname]; !ok {
+			ans = append(ans, fmt.Errorf("collDatabases '%s': corpus '%s' not described in corpora", db.ID, db.Corpname))
+		}
+		if db.Path == "" {
+			ans = append(ans, fmt.Errorf("collDatabases '%s': missing path", db.ID))
+
+		} else if dbPath, err := paths.CollDBPath(&db); err != nil {
+			ans = append(ans, fmt.Errorf("collDatabases '%s': invalid path: %w", db.ID, err))
+
+		} else if _, err := os.ReadDir(dbPath); err != nil {
+			ans = append(ans, fmt.Errorf("collDatabases '%s': unreadable path '%s': %w", db.ID, dbPath, err))
+		}
+	}
+	for i, m := range conf.Models {
+		if m.CollDatabaseID == "" {
+			continue
+		}
+		dbCorpus, ok := dbCorpora[m.CollDatabaseID]
+		if !ok {
+			ans = append(ans, fmt.Errorf("models[%d]: unknown collDatabaseId '%s'", i, m.CollDatabaseID))
+
+		} else if dbCorpus != m.Corpname {
+			ans = append(
+				ans,
+				fmt.Errorf(
+					"models[%d]: collDatabaseId '%s' belongs to a different corpus ('%s')",
+					i, m.CollDatabaseID, dbCorpus,
+				),
+			)
+		}
+	}
+	return ans
+}
@@ -5,15 +5,27 @@ import "fmt"
 type ErrorType string
 
 const (
-	ErrorTypeNotFound         ErrorType = "NOT_FOUND"
-	ErrorTypeInternalError    ErrorType = "INTERNAL_ERROR"
-	ErrorTypeInvalidArguments ErrorType = "INVALID_ARGUMENTS"
+	ErrorTypeNotFound           ErrorType = "NOT_FOUND"
+	ErrorTypeInternalError      ErrorType = "INTERNAL_ERROR"
+	ErrorTypeInvalidArguments   ErrorType = "INVALID_ARGUMENTS"
+	ErrorTypeUnsupportedFeature ErrorType = "UNSUPPORTED_FEATURE"
+	ErrorTypeModelLoading       ErrorType = "MODEL_LOADING"
+	ErrorTypeTimeout            ErrorType = "TIMEOUT"
+	ErrorTypeCancelled          ErrorType = "CANCELLED"
+	ErrorTypeRateLimited        ErrorType = "RATE_LIMITED"
+	ErrorTypeUnauthorized       ErrorType = "UNAUTHORIZED"
+	ErrorTypeForbidden          ErrorType = "FORBIDDEN"
+	ErrorTypeUnavailable        ErrorType = "UNAVAILABLE"
 )
 
 type AppError struct {
 	Message string
 	Type    ErrorType
 	Cause   error
+
+	// Param is an optional name of a request parameter
+	// which caused the error
+	Param string
 }
 
 func (err AppError) Error() string {
@@ -27,6 +39,13 @@ func (err AppError) IsZero() bool {
 	return err.Message == ""
 }
 
+// WithParam returns a copy of the error with the offending
+// request parameter attached
+func (err AppError) WithParam(name string) AppError {
+	err.Param = name
+	return err
+}
+
 func NewAppError(message string, etype ErrorType, cause error) AppError {
 	return AppError{
 		Message: message,
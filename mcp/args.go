@@ -0,0 +1,100 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	dfltLimit    = 10
+	dfltMinScore = 0
+	dfltSortBy   = "rrf"
+)
+
+// wordArgs contains arguments shared by all the word-oriented tools
+type wordArgs struct {
+	datasetID string
+	word      string
+	collDBID  string
+}
+
+func parseWordArgs(request mcp.CallToolRequest) (wordArgs, error) {
+	var ans wordArgs
+	var err error
+	ans.datasetID, err = request.RequireString("dataset_id")
+	if err != nil {
+		return ans, err
+	}
+	ans.word, err = request.RequireString("word")
+	if err != nil {
+		return ans, err
+	}
+	ans.collDBID = request.GetString("coll_db_id", "")
+	return ans, nil
+}
+
+func parseLimit(request mcp.CallToolRequest) (int, error) {
+	limit := request.GetInt("limit", dfltLimit)
+	if limit <= 0 {
+		return 0, fmt.Errorf("invalid limit %d, must be a positive number", limit)
+	}
+	return limit, nil
+}
+
+// collArgs contains arguments of the collocation tools
+type collArgs struct {
+	wordArgs
+	pos      string
+	textType string
+	limit    int
+	sortBy   storage.SortingMeasure
+}
+
+// options converts the arguments into respective calculation options
+func (args collArgs) options() []func(opts *scoll.CalculationOptions) {
+	return []func(opts *scoll.CalculationOptions){
+		scoll.WithPoS(args.pos),
+		scoll.WithTextType(args.textType),
+		scoll.WithLimit(args.limit),
+		scoll.WithSortBy(args.sortBy),
+	}
+}
+
+func parseCollArgs(request mcp.CallToolRequest) (collArgs, error) {
+	var ans collArgs
+	var err error
+	ans.wordArgs, err = parseWordArgs(request)
+	if err != nil {
+		return ans, err
+	}
+	ans.limit, err = parseLimit(request)
+	if err != nil {
+		return ans, err
+	}
+	ans.pos = request.GetString("pos", "")
+	ans.textType = request.GetString("text_type", "")
+	ans.sortBy = storage.SortingMeasure(request.GetString("sort_by", dfltSortBy))
+	if !ans.sortBy.Validate() {
+		return ans, fmt.Errorf("invalid sort_by value: %s", ans.sortBy)
+	}
+	return ans, nil
+}
@@ -0,0 +1,426 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/queries"
+	"github.com/czcorpus/wsserver/tracing"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// apiVersion is a path prefix of the wsserver API version
+	// the searcher is compatible with
+	apiVersion = "v1"
+
+	// maxRetryAfter is the longest server-suggested delay (Retry-After)
+	// the client is willing to wait before repeating a request
+	maxRetryAfter = 30 * time.Second
+)
+
+// HTTPClient is a simple JSON-oriented HTTP client with support
+// for retries (with exponential backoff) of failed requests.
+type HTTPClient struct {
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// isRetryable tells whether a response status suggests
+// that repeating the request may help. Timeouts (504) are not
+// retried as a repeated query would most likely time out too.
+func isRetryable(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable
+}
+
+// isTimeout tells whether a request failed due to a timeout (either
+// of the client or of the request context). Such requests are not
+// retried for the same reason as 504 responses.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses the Retry-After header value (either
+// seconds or an HTTP date). For a missing or invalid value, zero
+// is returned.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(0, secs)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(0, t.Sub(now))
+	}
+	return 0
+}
+
+// GET sends a GET request and returns response body along with
+// the response status code. Connection errors (but not timeouts)
+// and "temporary" HTTP errors (429, 502, 503) are retried. In case the server suggests
+// a delay via Retry-After, the delay is respected (or, if too long,
+// the error response is returned right away).
+func (c *HTTPClient) GET(
+	ctx context.Context,
+	reqURL string,
+	args url.Values,
+	headers map[string]string,
+) ([]byte, int, error) {
+	parsedURL, err := url.Parse(reqURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	parsedURL.RawQuery = args.Encode()
+
+	var body []byte
+	var status int
+	var retryAfter time.Duration
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		body, status, retryAfter, err = c.doGET(ctx, parsedURL.String(), headers)
+		if err == nil && !isRetryable(status) || err != nil && isTimeout(err) ||
+			attempt >= c.maxRetries || retryAfter > maxRetryAfter {
+			break
+		}
+		log.Warn().
+			Err(err).
+			Int("status", status).
+			Str("url", parsedURL.String()).
+			Int("attempt", attempt+1).
+			Msg("request to wsserver failed, going to retry")
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(max(delay, retryAfter)):
+		}
+		delay *= 2
+	}
+	return body, status, err
+}
+
+// doGET sends a single GET request. Along with the response body
+// and status, a server-suggested delay before a retry is returned.
+func (c *HTTPClient) doGET(
+	ctx context.Context,
+	reqURL string,
+	headers map[string]string,
+) ([]byte, int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Add(key, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer resp.Body.Close()
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, retryAfter, err
+	}
+	return body, resp.StatusCode, retryAfter, nil
+}
+
+// CloseIdleConnections closes connections kept for reuse
+func (c *HTTPClient) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+// NewHTTPClient is a recommended factory function for HTTPClient.
+// A negative maxRetries is considered zero. A nil transport means
+// http.DefaultTransport.
+func NewHTTPClient(
+	timeout time.Duration,
+	maxRetries int,
+	backoff time.Duration,
+	transport http.RoundTripper,
+) *HTTPClient {
+	return &HTTPClient{
+		client:     &http.Client{Timeout: timeout, Transport: tracing.HTTPTransport(transport)},
+		maxRetries: max(0, maxRetries),
+		backoff:    backoff,
+	}
+}
+
+// --------
+
+// statusToErrorType maps wsserver HTTP response status
+// back to application error types in case the response
+// does not contain a proper error envelope
+func statusToErrorType(status int) core.ErrorType {
+	switch status {
+	case http.StatusNotFound:
+		return core.ErrorTypeNotFound
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return core.ErrorTypeInvalidArguments
+	case http.StatusServiceUnavailable:
+		return core.ErrorTypeModelLoading
+	case http.StatusGatewayTimeout:
+		return core.ErrorTypeTimeout
+	case http.StatusTooManyRequests:
+		return core.ErrorTypeRateLimited
+	case http.StatusUnauthorized:
+		return core.ErrorTypeUnauthorized
+	case http.StatusForbidden:
+		return core.ErrorTypeForbidden
+	default:
+		return core.ErrorTypeInternalError
+	}
+}
+
+// HTTPClientSearcher implements GeneralSearcher by forwarding
+// queries to a running wsserver instance. Access restrictions of
+// a principal found in a query context are applied before a query
+// is forwarded.
+type HTTPClientSearcher struct {
+	client  *HTTPClient
+	baseURL string
+
+	// apiKey is sent to wsserver in case it is not empty
+	apiKey string
+}
+
+// escapePathSegment escapes a single URL path segment. Unlike
+// url.PathEscape, dot segments are escaped too so they cannot
+// change the resolved route.
+func escapePathSegment(seg string) string {
+	if seg == "." || seg == ".." {
+		return strings.Repeat("%2E", len(seg))
+	}
+	return url.PathEscape(seg)
+}
+
+// mkURL creates a versioned API URL from (unescaped) path segments.
+// The path is not cleaned (see escapePathSegment).
+func (searcher *HTTPClientSearcher) mkURL(segments ...string) string {
+	escaped := make([]string, len(segments)+1)
+	escaped[0] = apiVersion
+	for i, seg := range segments {
+		escaped[i+1] = escapePathSegment(seg)
+	}
+	return searcher.baseURL + "/" + strings.Join(escaped, "/")
+}
+
+// getJSON sends a request to wsserver and decodes its JSON response
+// into the target. Error responses are converted to core.AppError.
+func (searcher *HTTPClientSearcher) getJSON(
+	ctx context.Context,
+	apiURL string,
+	args url.Values,
+	target any,
+) core.AppError {
+	headers := map[string]string{}
+	if searcher.apiKey != "" {
+		headers[auth.APIKeyHeader] = searcher.apiKey
+	}
+	body, status, err := searcher.client.GET(ctx, apiURL, args, headers)
+	if err != nil && isTimeout(err) {
+		return core.NewAppError("wsserver query timed out", core.ErrorTypeTimeout, err)
+
+	} else if err != nil {
+		return core.NewAppError("failed to query wsserver", core.ErrorTypeInternalError, err)
+	}
+	if status >= 400 {
+		var errResp struct {
+			Error struct {
+				Code    core.ErrorType `json:"code"`
+				Message string         `json:"message"`
+				Param   string         `json:"param"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+			etype := errResp.Error.Code
+			if etype == "" {
+				etype = statusToErrorType(status)
+			}
+			return core.NewAppError(errResp.Error.Message, etype, nil).WithParam(errResp.Error.Param)
+		}
+		return core.NewAppError(
+			fmt.Sprintf("wsserver responded with status %d", status), statusToErrorType(status), nil)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return core.NewAppError("failed to decode wsserver response", core.ErrorTypeInternalError, err)
+	}
+	return core.AppError{}
+}
+
+func (searcher *HTTPClientSearcher) SimilarlyUsedWords(
+	ctx context.Context,
+	datasetID, modelID, posOrSfn, word string,
+	limit int,
+	minScore float32,
+) ([]queries.ResultRow, core.AppError) {
+	if appErr := auth.CheckAccess(ctx, datasetID, modelID); !appErr.IsZero() {
+		return []queries.ResultRow{}, appErr
+	}
+	segments := []string{"dataset", datasetID, "similarWords", modelID, word}
+	if posOrSfn != "" {
+		segments = append(segments, posOrSfn)
+	}
+	apiURL := searcher.mkURL(segments...)
+	args := url.Values{}
+	args.Set("minScore", strconv.FormatFloat(float64(minScore), 'f', -1, 32))
+	args.Set("limit", strconv.Itoa(limit))
+	ans := make([]queries.ResultRow, 0, min(50, limit))
+	if appErr := searcher.getJSON(ctx, apiURL, args, &ans); !appErr.IsZero() {
+		return []queries.ResultRow{}, appErr
+	}
+	return ans, core.AppError{}
+}
+
+// Collocations translates provided calculation options into
+// respective wsserver API arguments. Please note that only
+// options supported by the API (PoS, text type, limit, sorting
+// and predefined search) are forwarded.
+func (searcher *HTTPClientSearcher) Collocations(
+	ctx context.Context,
+	datasetID, collDBID, word string,
+	options ...func(opts *scoll.CalculationOptions),
+) ([]queries.SimpleCollocation, core.AppError) {
+	if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+		return []queries.SimpleCollocation{}, appErr
+	}
+	var opts scoll.CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	segments := []string{"dataset", datasetID, "collocations", word}
+	if opts.PredefinedSearch != "" {
+		segments = []string{"dataset", datasetID, "collocationsOfType", string(opts.PredefinedSearch), word}
+	}
+	if opts.PoS != "" {
+		segments = append(segments, opts.PoS)
+	}
+	apiURL := searcher.mkURL(segments...)
+	args := url.Values{}
+	if opts.Limit > 0 {
+		args.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.TextType != "" {
+		args.Set("tt", opts.TextType)
+	}
+	if opts.SortBy != "" {
+		args.Set("sortBy", string(opts.SortBy))
+	}
+	if collDBID != "" {
+		args.Set("collDb", collDBID)
+	}
+	var ans struct {
+		Items []queries.SimpleCollocation `json:"items"`
+	}
+	if appErr := searcher.getJSON(ctx, apiURL, args, &ans); !appErr.IsZero() {
+		return []queries.SimpleCollocation{}, appErr
+	}
+	return ans.Items, core.AppError{}
+}
+
+func (searcher *HTTPClientSearcher) Dictionary(
+	ctx context.Context,
+	datasetID, collDBID, word string,
+) ([]queries.DictItem, core.AppError) {
+	if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+		return []queries.DictItem{}, appErr
+	}
+	apiURL := searcher.mkURL("dataset", datasetID, "dictionary", word)
+	args := url.Values{}
+	if collDBID != "" {
+		args.Set("collDb", collDBID)
+	}
+	ans := make([]queries.DictItem, 0, 20)
+	if appErr := searcher.getJSON(ctx, apiURL, args, &ans); !appErr.IsZero() {
+		return []queries.DictItem{}, appErr
+	}
+	return ans, core.AppError{}
+}
+
+func (searcher *HTTPClientSearcher) Datasets(ctx context.Context) ([]queries.DatasetInfo, core.AppError) {
+	apiURL := searcher.mkURL("datasets")
+	ans := make([]queries.DatasetInfo, 0, 10)
+	if appErr := searcher.getJSON(ctx, apiURL, url.Values{}, &ans); !appErr.IsZero() {
+		return []queries.DatasetInfo{}, appErr
+	}
+	principal := auth.FromContext(ctx)
+	ans = slices.DeleteFunc(ans, func(d queries.DatasetInfo) bool { return !principal.CanAccessDataset(d.ID) })
+	for i, d := range ans {
+		ans[i].Models = slices.DeleteFunc(d.Models, func(m model.ModelStatus) bool {
+			return !principal.CanAccessModel(d.ID, m.ID)
+		})
+	}
+	return ans, core.AppError{}
+}
+
+func (searcher *HTTPClientSearcher) ListModels(ctx context.Context, datasetID string) ([]model.ModelInfo, core.AppError) {
+	if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+		return []model.ModelInfo{}, appErr
+	}
+	apiURL := searcher.mkURL("dataset", datasetID, "similarWords")
+	ans := make([]model.ModelInfo, 0, 5)
+	if appErr := searcher.getJSON(ctx, apiURL, url.Values{}, &ans); !appErr.IsZero() {
+		return []model.ModelInfo{}, appErr
+	}
+	principal := auth.FromContext(ctx)
+	ans = slices.DeleteFunc(ans, func(m model.ModelInfo) bool {
+		return !principal.CanAccessModel(datasetID, m.Name)
+	})
+	return ans, core.AppError{}
+}
+
+// Close closes idle connections to wsserver
+func (searcher *HTTPClientSearcher) Close(ctx context.Context) error {
+	searcher.client.CloseIdleConnections()
+	return nil
+}
+
+// NewHTTPClientSearcher is a recommended factory function for HTTPClientSearcher.
+// An empty apiKey means wsserver is accessed anonymously.
+func NewHTTPClientSearcher(baseURL string, client *HTTPClient, apiKey string) (*HTTPClientSearcher, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid wsserver URL '%s'", baseURL)
+	}
+	return &HTTPClientSearcher{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+	}, nil
+}
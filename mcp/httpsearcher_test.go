@@ -0,0 +1,354 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResponse is a single response of a fake wsserver
+type fakeResponse struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// newFakeServer creates a server responding with the provided responses
+// in order (the last one is repeated). The number of received requests
+// and the last requested path are recorded.
+func newFakeServer(t *testing.T, responses ...fakeResponse) (*httptest.Server, *atomic.Int32, *atomic.Value) {
+	var calls atomic.Int32
+	var lastPath atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		lastPath.Store(r.URL.EscapedPath())
+		resp := responses[min(n, len(responses))-1]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &lastPath
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isRetryable(tt.status), tt.status)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{"Sun, 01 Jun 2025 12:00:10 GMT", 10 * time.Second},
+		{"Sun, 01 Jun 2025 11:59:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfter(tt.value, now), tt.value)
+	}
+}
+
+func TestStatusToErrorType(t *testing.T) {
+	tests := []struct {
+		status int
+		want   core.ErrorType
+	}{
+		{http.StatusBadRequest, core.ErrorTypeInvalidArguments},
+		{http.StatusUnauthorized, core.ErrorTypeUnauthorized},
+		{http.StatusForbidden, core.ErrorTypeForbidden},
+		{http.StatusNotFound, core.ErrorTypeNotFound},
+		{http.StatusUnprocessableEntity, core.ErrorTypeInvalidArguments},
+		{http.StatusTooManyRequests, core.ErrorTypeRateLimited},
+		{http.StatusInternalServerError, core.ErrorTypeInternalError},
+		{http.StatusServiceUnavailable, core.ErrorTypeModelLoading},
+		{http.StatusGatewayTimeout, core.ErrorTypeTimeout},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, statusToErrorType(tt.status), tt.status)
+	}
+}
+
+func TestHTTPClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		responses  []fakeResponse
+		wantStatus int
+		wantCalls  int32
+	}{
+		{
+			name:       "success",
+			responses:  []fakeResponse{{status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantCalls:  1,
+		},
+		{
+			name: "temporary errors are retried",
+			responses: []fakeResponse{
+				{status: http.StatusServiceUnavailable, retryAfter: "0"},
+				{status: http.StatusBadGateway},
+				{status: http.StatusOK},
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+		},
+		{
+			name:       "retries are limited",
+			responses:  []fakeResponse{{status: http.StatusBadGateway}},
+			wantStatus: http.StatusBadGateway,
+			wantCalls:  3,
+		},
+		{
+			name:       "timeout is not retried",
+			responses:  []fakeResponse{{status: http.StatusGatewayTimeout}},
+			wantStatus: http.StatusGatewayTimeout,
+			wantCalls:  1,
+		},
+		{
+			name:       "client error is not retried",
+			responses:  []fakeResponse{{status: http.StatusNotFound}},
+			wantStatus: http.StatusNotFound,
+			wantCalls:  1,
+		},
+		{
+			name:       "too long Retry-After is not waited for",
+			responses:  []fakeResponse{{status: http.StatusTooManyRequests, retryAfter: "3600"}},
+			wantStatus: http.StatusTooManyRequests,
+			wantCalls:  1,
+		},
+	}
+	for _, tt := range tests {
+		srv, calls, _ := newFakeServer(t, tt.responses...)
+		client := NewHTTPClient(time.Second, 2, time.Millisecond, nil)
+		_, status, err := client.GET(context.Background(), srv.URL, url.Values{}, nil)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.wantStatus, status, tt.name)
+		assert.Equal(t, tt.wantCalls, calls.Load(), tt.name)
+	}
+}
+
+func TestHTTPClientRespectsRetryAfter(t *testing.T) {
+	srv, calls, _ := newFakeServer(
+		t,
+		fakeResponse{status: http.StatusTooManyRequests, retryAfter: "1"},
+		fakeResponse{status: http.StatusOK},
+	)
+	client := NewHTTPClient(time.Second, 2, time.Millisecond, nil)
+	start := time.Now()
+	_, status, err := client.GET(context.Background(), srv.URL, url.Values{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestHTTPClientSearcherErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  fakeResponse
+		wantType  core.ErrorType
+		wantMsg   string
+		wantParam string
+	}{
+		{
+			name: "error envelope",
+			response: fakeResponse{
+				status: http.StatusForbidden,
+				body:   `{"error":{"code":"FORBIDDEN","message":"access to model m1 denied","param":"modelId"}}`,
+			},
+			wantType:  core.ErrorTypeForbidden,
+			wantMsg:   "access to model m1 denied",
+			wantParam: "modelId",
+		},
+		{
+			name: "envelope without a code",
+			response: fakeResponse{
+				status: http.StatusNotFound,
+				body:   `{"error":{"message":"unknown dataset foo"}}`,
+			},
+			wantType: core.ErrorTypeNotFound,
+			wantMsg:  "unknown dataset foo",
+		},
+		{
+			name:     "no envelope",
+			response: fakeResponse{status: http.StatusGatewayTimeout, body: "gateway timeout"},
+			wantType: core.ErrorTypeTimeout,
+			wantMsg:  "wsserver responded with status 504",
+		},
+		{
+			name:     "invalid response",
+			response: fakeResponse{status: http.StatusOK, body: "not a JSON"},
+			wantType: core.ErrorTypeInternalError,
+			wantMsg:  "failed to decode wsserver response",
+		},
+	}
+	for _, tt := range tests {
+		srv, _, _ := newFakeServer(t, tt.response)
+		searcher, err := NewHTTPClientSearcher(srv.URL, NewHTTPClient(time.Second, 0, time.Millisecond, nil), "")
+		assert.NoError(t, err)
+		_, appErr := searcher.Dictionary(context.Background(), "syn2020", "", "pes")
+		assert.Equal(t, tt.wantType, appErr.Type, tt.name)
+		assert.Equal(t, tt.wantMsg, appErr.Message, tt.name)
+		assert.Equal(t, tt.wantParam, appErr.Param, tt.name)
+	}
+}
+
+func TestHTTPClientSearcherCollocationsURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(opts *scoll.CalculationOptions)
+		want    string
+	}{
+		{
+			name: "plain",
+			want: "/v1/dataset/syn2020/collocations/d%C5%AFm",
+		},
+		{
+			name:    "with PoS",
+			options: []func(opts *scoll.CalculationOptions){scoll.WithPoS("N")},
+			want:    "/v1/dataset/syn2020/collocations/d%C5%AFm/N",
+		},
+		{
+			name:    "predefined search",
+			options: []func(opts *scoll.CalculationOptions){scoll.WithPredefinedSearch(scoll.ModifiersOf)},
+			want:    "/v1/dataset/syn2020/collocationsOfType/modifiers-of/d%C5%AFm",
+		},
+		{
+			name: "predefined search with PoS",
+			options: []func(opts *scoll.CalculationOptions){
+				scoll.WithPredefinedSearch(scoll.ModifiersOf), scoll.WithPoS("N")},
+			want: "/v1/dataset/syn2020/collocationsOfType/modifiers-of/d%C5%AFm/N",
+		},
+	}
+	for _, tt := range tests {
+		srv, _, lastPath := newFakeServer(t, fakeResponse{status: http.StatusOK, body: `{"items":[]}`})
+		searcher, err := NewHTTPClientSearcher(srv.URL, NewHTTPClient(time.Second, 0, time.Millisecond, nil), "")
+		assert.NoError(t, err)
+		_, appErr := searcher.Collocations(context.Background(), "syn2020", "", "dům", tt.options...)
+		assert.True(t, appErr.IsZero(), tt.name)
+		assert.Equal(t, tt.want, lastPath.Load(), tt.name)
+	}
+}
+
+func TestHTTPClientSearcherDotSegments(t *testing.T) {
+	tests := []struct {
+		name  string
+		lemma string
+		want  string
+	}{
+		{"plain lemma", "pes", "/wss/v1/dataset/syn2020/dictionary/pes"},
+		{"single dot", ".", "/wss/v1/dataset/syn2020/dictionary/%2E"},
+		{"double dot", "..", "/wss/v1/dataset/syn2020/dictionary/%2E%2E"},
+		{"dots within lemma", "a..b", "/wss/v1/dataset/syn2020/dictionary/a..b"},
+		{"slash", "a/b", "/wss/v1/dataset/syn2020/dictionary/a%2Fb"},
+	}
+	for _, tt := range tests {
+		srv, _, lastPath := newFakeServer(t, fakeResponse{status: http.StatusOK, body: `[]`})
+		searcher, err := NewHTTPClientSearcher(srv.URL+"/wss/", NewHTTPClient(time.Second, 0, time.Millisecond, nil), "")
+		assert.NoError(t, err)
+		_, appErr := searcher.Dictionary(context.Background(), "syn2020", "", tt.lemma)
+		assert.True(t, appErr.IsZero(), tt.name)
+		assert.Equal(t, tt.want, lastPath.Load(), tt.name)
+	}
+}
+
+func TestHTTPClientTransportErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     func(w http.ResponseWriter, r *http.Request)
+		wantTimeout bool
+		wantCalls   int32
+	}{
+		{
+			name: "client timeout is not retried",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			wantTimeout: true,
+			wantCalls:   1,
+		},
+		{
+			name: "connection error is retried",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+			},
+			wantCalls: 3,
+		},
+	}
+	for _, tt := range tests {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			tt.handler(w, r)
+		}))
+		client := NewHTTPClient(50*time.Millisecond, 2, time.Millisecond, nil)
+		_, _, err := client.GET(context.Background(), srv.URL, url.Values{}, nil)
+		srv.Close()
+		assert.Error(t, err, tt.name)
+		assert.Equal(t, tt.wantTimeout, isTimeout(err), tt.name)
+		assert.Equal(t, tt.wantCalls, calls.Load(), tt.name)
+	}
+}
+
+func TestHTTPClientSearcherTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	searcher, err := NewHTTPClientSearcher(srv.URL, NewHTTPClient(50*time.Millisecond, 2, time.Millisecond, nil), "")
+	assert.NoError(t, err)
+	_, appErr := searcher.Dictionary(context.Background(), "syn2020", "", "pes")
+	assert.Equal(t, core.ErrorTypeTimeout, appErr.Type)
+}
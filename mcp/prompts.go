@@ -0,0 +1,300 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/wsserver/queries"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type promptHandler struct {
+	searcher GeneralSearcher
+}
+
+// requireArg returns a non-empty prompt argument or an error
+func requireArg(request mcp.GetPromptRequest, name string) (string, error) {
+	v := strings.TrimSpace(request.Params.Arguments[name])
+	if v == "" {
+		return "", fmt.Errorf("missing required argument '%s'", name)
+	}
+	return v, nil
+}
+
+// findDataset looks up a dataset so prompts can refer only to tools
+// and models the dataset actually supports
+func (ph *promptHandler) findDataset(ctx context.Context, datasetID string) (queries.DatasetInfo, error) {
+	datasets, appErr := ph.searcher.Datasets(ctx)
+	if !appErr.IsZero() {
+		return queries.DatasetInfo{}, fmt.Errorf("failed to obtain datasets: %w", appErr)
+	}
+	idx := slices.IndexFunc(datasets, func(ds queries.DatasetInfo) bool { return ds.ID == datasetID })
+	if idx < 0 {
+		return queries.DatasetInfo{}, fmt.Errorf("unknown dataset '%s'", datasetID)
+	}
+	return datasets[idx], nil
+}
+
+// modelHint describes which model should be used for similarity queries.
+// Models with PoS information are preferred.
+func modelHint(ds queries.DatasetInfo) string {
+	if len(ds.Models) == 0 {
+		return ""
+	}
+	best := ds.Models[0]
+	for _, m := range ds.Models {
+		if m.ContainsPoS {
+			best = m
+			break
+		}
+	}
+	return fmt.Sprintf("model_id=\"%s\"", best.ID)
+}
+
+func mkPromptResult(description, intro string, steps []string, closing string) *mcp.GetPromptResult {
+	var text strings.Builder
+	text.WriteString(intro)
+	text.WriteString("\n\n")
+	for i, step := range steps {
+		text.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+	}
+	text.WriteString("\n")
+	text.WriteString(closing)
+	return mcp.NewGetPromptResult(
+		description,
+		[]mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String())),
+		},
+	)
+}
+
+func (ph *promptHandler) describeUsage(
+	ctx context.Context,
+	request mcp.GetPromptRequest,
+) (*mcp.GetPromptResult, error) {
+	datasetID, err := requireArg(request, "dataset_id")
+	if err != nil {
+		return nil, err
+	}
+	lemma, err := requireArg(request, "lemma")
+	if err != nil {
+		return nil, err
+	}
+	ds, err := ph.findDataset(ctx, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	intro := fmt.Sprintf(
+		"Describe the usage of the lemma \"%s\" in the dataset \"%s\" using the tools listed below.",
+		lemma, datasetID)
+	steps := make([]string, 0, 3)
+	if ds.HasFeature(queries.FeatureDictionary) {
+		steps = append(steps, fmt.Sprintf(
+			"Call `dictionary` with dataset_id=\"%s\", word=\"%s\" to find out the lemma's "+
+				"parts of speech, text types and frequencies.", datasetID, lemma))
+	}
+	if ds.HasFeature(queries.FeatureCollocationsOfType) {
+		steps = append(steps, fmt.Sprintf(
+			"For each relevant part of speech, call `collocations_of_type` with dataset_id=\"%s\", "+
+				"word=\"%s\" and a fitting type (%s) to get typical modifiers, subjects and objects.",
+			datasetID, lemma, strings.Join(predefinedSearchs, ", ")))
+
+	} else if ds.HasFeature(queries.FeatureCollocations) {
+		steps = append(steps, fmt.Sprintf(
+			"Call `collocations` with dataset_id=\"%s\", word=\"%s\" to get the lemma's "+
+				"typical syntactic collocates.", datasetID, lemma))
+	}
+	if ds.HasFeature(queries.FeatureSimilarWords) {
+		steps = append(steps, fmt.Sprintf(
+			"Call `similarly_used_words` with dataset_id=\"%s\", %s, word=\"%s\" to find words "+
+				"used in similar contexts.", datasetID, modelHint(ds), lemma))
+	}
+	return mkPromptResult(
+		fmt.Sprintf("Usage of the lemma '%s' in %s", lemma, datasetID),
+		intro,
+		steps,
+		"Summarize the findings as a short dictionary-like entry: meanings (as suggested by "+
+			"the collocates), typical constructions, register/text type preferences and related words. "+
+			"Support each claim with concrete data returned by the tools.",
+	), nil
+}
+
+func (ph *promptHandler) compareLemmas(
+	ctx context.Context,
+	request mcp.GetPromptRequest,
+) (*mcp.GetPromptResult, error) {
+	datasetID, err := requireArg(request, "dataset_id")
+	if err != nil {
+		return nil, err
+	}
+	lemma1, err := requireArg(request, "lemma1")
+	if err != nil {
+		return nil, err
+	}
+	lemma2, err := requireArg(request, "lemma2")
+	if err != nil {
+		return nil, err
+	}
+	ds, err := ph.findDataset(ctx, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	intro := fmt.Sprintf(
+		"Compare the usage of the lemmas \"%s\" and \"%s\" in the dataset \"%s\" using the tools listed below.",
+		lemma1, lemma2, datasetID)
+	steps := make([]string, 0, 3)
+	if ds.HasFeature(queries.FeatureDictionary) {
+		steps = append(steps, fmt.Sprintf(
+			"Call `dictionary` with dataset_id=\"%s\" for word=\"%s\" and for word=\"%s\" to compare "+
+				"their parts of speech, text types and frequencies.", datasetID, lemma1, lemma2))
+	}
+	if ds.HasFeature(queries.FeatureCollocations) {
+		steps = append(steps, fmt.Sprintf(
+			"Call `collocations` with dataset_id=\"%s\" for both lemmas (use the same pos and limit, "+
+				"e.g. limit=30) and identify shared and distinctive collocates.", datasetID))
+	}
+	if ds.HasFeature(queries.FeatureSimilarWords) {
+		steps = append(steps, fmt.Sprintf(
+			"Call `similarly_used_words` with dataset_id=\"%s\", %s for both lemmas and check "+
+				"whether each lemma appears among the other one's similar words.",
+			datasetID, modelHint(ds)))
+	}
+	return mkPromptResult(
+		fmt.Sprintf("Comparison of the lemmas '%s' and '%s' in %s", lemma1, lemma2, datasetID),
+		intro,
+		steps,
+		"Summarize what the lemmas have in common and how they differ (meaning, typical "+
+			"collocates, register). Support each claim with concrete data returned by the tools.",
+	), nil
+}
+
+func (ph *promptHandler) findSynonyms(
+	ctx context.Context,
+	request mcp.GetPromptRequest,
+) (*mcp.GetPromptResult, error) {
+	datasetID, err := requireArg(request, "dataset_id")
+	if err != nil {
+		return nil, err
+	}
+	lemma, err := requireArg(request, "lemma")
+	if err != nil {
+		return nil, err
+	}
+	textType := strings.TrimSpace(request.Params.Arguments["text_type"])
+	ds, err := ph.findDataset(ctx, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if !ds.HasFeature(queries.FeatureSimilarWords) {
+		return nil, fmt.Errorf("dataset '%s' does not provide any word similarity model", datasetID)
+	}
+	intro := fmt.Sprintf(
+		"Find synonyms of the lemma \"%s\" in the dataset \"%s\" using the tools listed below.",
+		lemma, datasetID)
+	steps := []string{
+		fmt.Sprintf(
+			"Call `similarly_used_words` with dataset_id=\"%s\", %s, word=\"%s\", limit=30 to get "+
+				"synonym candidates.", datasetID, modelHint(ds), lemma),
+	}
+	if textType != "" && ds.HasFeature(queries.FeatureDictionary) {
+		steps = append(steps, fmt.Sprintf(
+			"For each candidate, call `dictionary` with dataset_id=\"%s\" and keep only candidates "+
+				"occurring in the text type \"%s\".", datasetID, textType))
+	}
+	if ds.HasFeature(queries.FeatureCollocations) {
+		ttArg := ""
+		if textType != "" {
+			ttArg = fmt.Sprintf(", text_type=\"%s\"", textType)
+		}
+		steps = append(steps, fmt.Sprintf(
+			"Call `collocations` with dataset_id=\"%s\"%s for the lemma and the most promising "+
+				"candidates and discard candidates sharing only few collocates with the lemma "+
+				"(those are rather related words or antonyms).", datasetID, ttArg))
+	}
+	description := fmt.Sprintf("Synonyms of the lemma '%s' in %s", lemma, datasetID)
+	if textType != "" {
+		description += fmt.Sprintf(" (text type %s)", textType)
+	}
+	return mkPromptResult(
+		description,
+		intro,
+		steps,
+		"Present the resulting synonyms ordered by their suitability and briefly justify "+
+			"each one with data returned by the tools.",
+	), nil
+}
+
+func registerPrompts(s *server.MCPServer, searcher GeneralSearcher) {
+	ph := &promptHandler{searcher: searcher}
+
+	s.AddPrompt(
+		mcp.NewPrompt("describe_lemma_usage",
+			mcp.WithPromptDescription("Describe the usage of a lemma based on its collocations and similar words"),
+			mcp.WithArgument("dataset_id",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The dataset ID to search in"),
+			),
+			mcp.WithArgument("lemma",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The lemma to describe"),
+			),
+		),
+		ph.describeUsage,
+	)
+
+	s.AddPrompt(
+		mcp.NewPrompt("compare_lemmas",
+			mcp.WithPromptDescription("Compare the usage of two lemmas"),
+			mcp.WithArgument("dataset_id",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The dataset ID to search in"),
+			),
+			mcp.WithArgument("lemma1",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The first lemma"),
+			),
+			mcp.WithArgument("lemma2",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The second lemma"),
+			),
+		),
+		ph.compareLemmas,
+	)
+
+	s.AddPrompt(
+		mcp.NewPrompt("find_synonyms",
+			mcp.WithPromptDescription("Find synonyms of a lemma, optionally within a text type"),
+			mcp.WithArgument("dataset_id",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The dataset ID to search in"),
+			),
+			mcp.WithArgument("lemma",
+				mcp.RequiredArgument(),
+				mcp.ArgumentDescription("The lemma to find synonyms for"),
+			),
+			mcp.WithArgument("text_type",
+				mcp.ArgumentDescription("Text type the synonyms should be typical for"),
+			),
+		),
+		ph.findSynonyms,
+	)
+}
@@ -0,0 +1,260 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/corpora"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	resourceURIPrefix  = "wss://dataset/"
+	jsonMIMEType       = "application/json"
+	dictionaryTemplate = resourceURIPrefix + "{datasetId}/dictionary/{lemma}"
+)
+
+func mkDatasetURI(datasetID string) string {
+	return resourceURIPrefix + datasetID
+}
+
+func mkModelURI(datasetID, modelID string) string {
+	return resourceURIPrefix + datasetID + "/model/" + modelID
+}
+
+type resourceRef struct {
+	ID  string `json:"id"`
+	URI string `json:"uri"`
+}
+
+type datasetResource struct {
+	corpora.Info
+	ID     string        `json:"id"`
+	Models []resourceRef `json:"models"`
+}
+
+type modelResource struct {
+	ID          string `json:"id"`
+	DatasetID   string `json:"datasetId"`
+	DatasetURI  string `json:"datasetUri"`
+	Description string `json:"description"`
+	ContainsPoS bool   `json:"containsPos"`
+	Size        int    `json:"size"`
+	Error       string `json:"error,omitempty"`
+}
+
+// canAccessResource tells whether the client of the request
+// can access a dataset or model resource. Other resources
+// (e.g. templates) are accessible to anyone.
+func canAccessResource(principal *auth.Principal, uri string) bool {
+	datasetPath, ok := strings.CutPrefix(uri, resourceURIPrefix)
+	if !ok {
+		return true
+	}
+	datasetID, modelID, isModel := strings.Cut(datasetPath, "/model/")
+	if isModel {
+		return principal.CanAccessModel(datasetID, modelID)
+	}
+	return principal.CanAccessDataset(datasetID)
+}
+
+// filterResourceList removes resources the client cannot access
+// from a resources/list result. It is intended as a server hook.
+func filterResourceList(
+	ctx context.Context,
+	_ any,
+	_ *mcp.ListResourcesRequest,
+	result *mcp.ListResourcesResult,
+) {
+	principal := auth.FromContext(ctx)
+	result.Resources = slices.DeleteFunc(
+		result.Resources,
+		func(r mcp.Resource) bool { return !canAccessResource(principal, r.URI) },
+	)
+}
+
+// templateArg extracts a URI template variable value
+func templateArg(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func jsonContents(uri string, data any) ([]mcp.ResourceContents, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource %s: %w", uri, err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: jsonMIMEType,
+			Text:     string(encoded),
+		},
+	}, nil
+}
+
+type resourceHandler struct {
+	searcher GeneralSearcher
+	corpora  map[string]corpora.Info
+	models   []model.ModelConf
+}
+
+// datasetIDs returns IDs of all the datasets known either
+// from corpora or from models configuration
+func (rh *resourceHandler) datasetIDs() []string {
+	ans := make([]string, 0, len(rh.corpora))
+	for corpusID := range rh.corpora {
+		ans = append(ans, corpusID)
+	}
+	for _, m := range rh.models {
+		if !slices.Contains(ans, m.Corpname) {
+			ans = append(ans, m.Corpname)
+		}
+	}
+	slices.Sort(ans)
+	return ans
+}
+
+func (rh *resourceHandler) readDataset(datasetID string) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+			return nil, appErr
+		}
+		principal := auth.FromContext(ctx)
+		ans := datasetResource{
+			Info:   rh.corpora[datasetID],
+			ID:     datasetID,
+			Models: make([]resourceRef, 0, 5),
+		}
+		for _, m := range rh.models {
+			if m.Corpname == datasetID && principal.CanAccessModel(datasetID, m.ID) {
+				ans.Models = append(ans.Models, resourceRef{ID: m.ID, URI: mkModelURI(datasetID, m.ID)})
+			}
+		}
+		return jsonContents(request.Params.URI, ans)
+	}
+}
+
+func (rh *resourceHandler) readModel(conf model.ModelConf) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if appErr := auth.CheckAccess(ctx, conf.Corpname, conf.ID); !appErr.IsZero() {
+			return nil, appErr
+		}
+		ans := modelResource{
+			ID:          conf.ID,
+			DatasetID:   conf.Corpname,
+			DatasetURI:  mkDatasetURI(conf.Corpname),
+			Description: conf.Description,
+			ContainsPoS: conf.ContainsPoS,
+		}
+		infos, appErr := rh.searcher.ListModels(ctx, conf.Corpname)
+		if !appErr.IsZero() {
+			return nil, appErr
+		}
+		for _, info := range infos {
+			if info.Name == conf.ID {
+				ans.Size = info.Size
+				ans.Error = info.Error
+			}
+		}
+		return jsonContents(request.Params.URI, ans)
+	}
+}
+
+func (rh *resourceHandler) readDictionary(
+	ctx context.Context,
+	request mcp.ReadResourceRequest,
+) ([]mcp.ResourceContents, error) {
+	datasetID := templateArg(request, "datasetId")
+	lemma := templateArg(request, "lemma")
+	if datasetID == "" || lemma == "" {
+		return nil, fmt.Errorf("invalid dictionary resource URI %s", request.Params.URI)
+	}
+	items, appErr := rh.searcher.Dictionary(ctx, datasetID, "", lemma)
+	if !appErr.IsZero() {
+		return nil, appErr
+	}
+	return jsonContents(request.Params.URI, items)
+}
+
+func registerResources(
+	s *server.MCPServer,
+	searcher GeneralSearcher,
+	corpInfo map[string]corpora.Info,
+	models []model.ModelConf,
+) {
+	rh := &resourceHandler{
+		searcher: searcher,
+		corpora:  corpInfo,
+		models:   models,
+	}
+	for _, datasetID := range rh.datasetIDs() {
+		desc := rh.corpora[datasetID].Description
+		if desc == "" {
+			desc = fmt.Sprintf("Dataset %s", datasetID)
+		}
+		s.AddResource(
+			mcp.NewResource(
+				mkDatasetURI(datasetID),
+				datasetID,
+				mcp.WithResourceDescription(desc+" (description, citation info, size and models)"),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			rh.readDataset(datasetID),
+		)
+	}
+	for _, m := range rh.models {
+		desc := m.Description
+		if desc == "" {
+			desc = fmt.Sprintf("Word embedding model %s", m.ID)
+		}
+		s.AddResource(
+			mcp.NewResource(
+				mkModelURI(m.Corpname, m.ID),
+				m.Corpname+"/"+m.ID,
+				mcp.WithResourceDescription(desc+" (reading the resource loads the model)"),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			rh.readModel(m),
+		)
+	}
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			dictionaryTemplate,
+			"dictionary entry",
+			mcp.WithTemplateDescription(
+				"Parts of speech, text types and frequencies of a lemma in a dataset"),
+			mcp.WithTemplateMIMEType(jsonMIMEType),
+		),
+		rh.readDictionary,
+	)
+}
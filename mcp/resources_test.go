@@ -0,0 +1,80 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/config"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterResourceList(t *testing.T) {
+	authn, err := auth.NewAuthenticator(config.AuthConf{
+		Enabled:        true,
+		PublicDatasets: []string{"syn2015"},
+		APIKeys: []config.APIKeyConf{
+			{Name: "restricted", Key: "k1", Datasets: []string{"syn2020"}, Models: []string{"syn2020/m1"}},
+		},
+	})
+	assert.NoError(t, err)
+	anonymous, _ := authn.Authenticate("")
+	restricted, _ := authn.Authenticate("k1")
+	uris := []string{
+		mkDatasetURI("syn2015"),
+		mkModelURI("syn2015", "m1"),
+		mkDatasetURI("syn2020"),
+		mkModelURI("syn2020", "m1"),
+		mkModelURI("syn2020", "m2"),
+	}
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      []string
+	}{
+		{
+			name:      "authentication disabled",
+			principal: nil,
+			want:      uris,
+		},
+		{
+			name:      "anonymous client",
+			principal: anonymous,
+			want:      []string{mkDatasetURI("syn2015"), mkModelURI("syn2015", "m1")},
+		},
+		{
+			name:      "key restricted to a model",
+			principal: restricted,
+			want:      []string{mkDatasetURI("syn2020"), mkModelURI("syn2020", "m1")},
+		},
+	}
+	for _, tt := range tests {
+		result := &mcp.ListResourcesResult{}
+		for _, uri := range uris {
+			result.Resources = append(result.Resources, mcp.NewResource(uri, uri))
+		}
+		filterResourceList(auth.WithPrincipal(context.Background(), tt.principal), nil, nil, result)
+		ans := make([]string, len(result.Resources))
+		for i, r := range result.Resources {
+			ans[i] = r.URI
+		}
+		assert.Equal(t, tt.want, ans, tt.name)
+	}
+}
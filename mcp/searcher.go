@@ -0,0 +1,74 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/netutil"
+	"github.com/czcorpus/wsserver/queries"
+)
+
+// NewSearcher creates a searcher based on the MCP configuration.
+// In the self-contained mode, models and collocation databases are
+// opened directly. Otherwise, queries are forwarded to a running
+// wsserver instance.
+func NewSearcher(conf *config.Config) (GeneralSearcher, error) {
+	if !conf.MCP.SelfContained {
+		transport, err := netutil.NewClientTransport(conf.MCP)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure connection to wsserver: %w", err)
+		}
+		searcher, err := NewHTTPClientSearcher(
+			conf.MCP.ServerURL,
+			NewHTTPClient(
+				time.Duration(conf.MCP.RequestTimeoutSecs)*time.Second,
+				conf.MCP.MaxRetries,
+				time.Duration(conf.MCP.RetryBackoffMs)*time.Millisecond,
+				transport,
+			),
+			conf.MCP.APIKey,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate HTTP searcher: %w", err)
+		}
+		return searcher, nil
+	}
+	collDbConfs, problems := conf.EffectiveCollDatabases()
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid collocation databases configuration: %w", errors.Join(problems...))
+	}
+	collDbMap, err := queries.NewCollDbMap(conf.PathResolver(), collDbConfs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate collocation databases: %w", err)
+	}
+	searcher, err := queries.NewSearchProvider(
+		conf.DataDir,
+		collDbMap,
+		model.NewProvider(conf.PathResolver(), conf.Models, conf.RateLimit.MaxConcurrentScans),
+		conf.Corpora,
+		queries.NewResultCache(conf.ResultCache),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate searcher: %w", err)
+	}
+	return searcher, nil
+}
@@ -1 +1,85 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mcp provides an MCP server exposing wsserver's tools,
+// resources and prompts. The server can be backed either by
+// a local queries.SearchProvider or by a remote wsserver instance
+// (see HTTPClientSearcher) and it can be served via stdio, HTTP
+// or mounted into an existing gin engine.
 package mcp
+
+import (
+	"context"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/corpora"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/queries"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// GeneralSearcher defines all the queries needed by the MCP server.
+// It is implemented by queries.SearchProvider and HTTPClientSearcher.
+type GeneralSearcher interface {
+	SimilarlyUsedWords(
+		ctx context.Context,
+		datasetID, modelID, posOrSfn, word string,
+		limit int,
+		minScore float32,
+	) ([]queries.ResultRow, core.AppError)
+
+	Collocations(
+		ctx context.Context,
+		datasetID, collDBID, word string,
+		options ...func(opts *scoll.CalculationOptions),
+	) ([]queries.SimpleCollocation, core.AppError)
+
+	Dictionary(ctx context.Context, datasetID, collDBID, word string) ([]queries.DictItem, core.AppError)
+
+	Datasets(ctx context.Context) ([]queries.DatasetInfo, core.AppError)
+
+	ListModels(ctx context.Context, datasetID string) ([]model.ModelInfo, core.AppError)
+
+	// Close releases resources held by the searcher
+	Close(ctx context.Context) error
+}
+
+// NewServer creates an MCP server with all the wsserver tools,
+// resources and prompts registered
+func NewServer(
+	searcher GeneralSearcher,
+	corpInfo map[string]corpora.Info,
+	models []model.ModelConf,
+) *server.MCPServer {
+	hooks := &server.Hooks{}
+	// resources are checked on read too, the listing just does not
+	// advertise datasets and models the client cannot access
+	hooks.AddAfterListResources(filterResourceList)
+	s := server.NewMCPServer(
+		"WSServer",
+		"0.0.2",
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
+		server.WithRecovery(),
+		server.WithHooks(hooks),
+	)
+	registerTools(s, searcher)
+	registerResources(s, searcher, corpInfo, models)
+	registerPrompts(s, searcher)
+	return s
+}
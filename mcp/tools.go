@@ -0,0 +1,447 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/queries"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	sortingMeasures   = []string{"rrf", "ldice", "tscore", "lmi"}
+	predefinedSearchs = []string{
+		string(scoll.ModifiersOf),
+		string(scoll.NounsModifiedBy),
+		string(scoll.VerbsSubject),
+		string(scoll.VerbsObject),
+	}
+)
+
+// SimilarWordsResult is a structured result of the similarly_used_words tool
+type SimilarWordsResult struct {
+	DatasetID string              `json:"datasetId"`
+	ModelID   string              `json:"modelId"`
+	Word      string              `json:"word"`
+	PosOrSfn  string              `json:"posOrSfn,omitempty"`
+	Items     []queries.ResultRow `json:"items"`
+}
+
+// CollocationsResult is a structured result of the collocations
+// and collocations_of_type tools
+type CollocationsResult struct {
+	DatasetID string                      `json:"datasetId"`
+	CollDBID  string                      `json:"collDbId,omitempty"`
+	Word      string                      `json:"word"`
+	Items     []queries.SimpleCollocation `json:"items"`
+}
+
+// DictionaryResult is a structured result of the dictionary tool
+type DictionaryResult struct {
+	DatasetID string             `json:"datasetId"`
+	CollDBID  string             `json:"collDbId,omitempty"`
+	Word      string             `json:"word"`
+	Items     []queries.DictItem `json:"items"`
+}
+
+// DatasetsResult is a structured result of the list_datasets tool
+type DatasetsResult struct {
+	Datasets []queries.DatasetInfo `json:"datasets"`
+}
+
+// toolError creates a tool result describing an application error
+// including its machine-readable code
+func toolError(err core.AppError) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("Error [%s]: %s", err.Type, err.Error()))
+}
+
+type toolHandler struct {
+	searcher GeneralSearcher
+}
+
+func (th *toolHandler) similarlyUsedWords(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	log.Debug().Msg("method invoked: similarly_used_words")
+	args, err := parseWordArgs(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	modelID, err := request.RequireString("model_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	limit, err := parseLimit(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	posOrSfn := request.GetString("pos_or_sfn", "")
+	minScore := request.GetFloat("min_score", dfltMinScore)
+	datasetID, word := args.datasetID, args.word
+
+	results, appErr := th.searcher.SimilarlyUsedWords(
+		ctx, datasetID, modelID, posOrSfn, word, limit, float32(minScore))
+	if !appErr.IsZero() {
+		return toolError(appErr), nil
+	}
+	var formattedRes strings.Builder
+	formattedRes.WriteString(
+		fmt.Sprintf("Words similar to '%s' (dataset %s, model %s):\n", word, datasetID, modelID))
+	for i, res := range results {
+		formattedRes.WriteString(fmt.Sprintf("%d. %s", i+1, res.Word))
+		if fns := slices.DeleteFunc(slices.Clone(res.SyntaxFn), func(v string) bool { return v == "" }); len(fns) > 0 {
+			formattedRes.WriteString(fmt.Sprintf(" [%s]", strings.Join(fns, ", ")))
+		}
+		formattedRes.WriteString(fmt.Sprintf(" (score %01.2f)\n", res.Score))
+	}
+	return mcp.NewToolResultStructured(
+		SimilarWordsResult{
+			DatasetID: datasetID,
+			ModelID:   modelID,
+			Word:      word,
+			PosOrSfn:  posOrSfn,
+			Items:     results,
+		},
+		formattedRes.String(),
+	), nil
+}
+
+func (th *toolHandler) formatCollocations(results []queries.SimpleCollocation) string {
+	var formattedRes strings.Builder
+	for i, res := range results {
+		formattedRes.WriteString(
+			fmt.Sprintf(
+				"%d. %s (%s), deprel: %s, logDice: %v, T-score: %v, LMI: %v, LL: %v, RRF: %v\n",
+				i+1, res.Collocate.Value, res.Collocate.PoS, res.Deprel,
+				res.LogDice, res.TScore, res.LMI, res.LL, res.RRF,
+			),
+		)
+	}
+	return formattedRes.String()
+}
+
+func (th *toolHandler) collocations(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	log.Debug().Msg("method invoked: collocations")
+	args, err := parseCollArgs(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	results, appErr := th.searcher.Collocations(
+		ctx,
+		args.datasetID,
+		args.collDBID,
+		args.word,
+		args.options()...,
+	)
+	if !appErr.IsZero() {
+		return toolError(appErr), nil
+	}
+	return mcp.NewToolResultStructured(
+		CollocationsResult{
+			DatasetID: args.datasetID,
+			CollDBID:  args.collDBID,
+			Word:      args.word,
+			Items:     results,
+		},
+		th.formatCollocations(results),
+	), nil
+}
+
+func (th *toolHandler) collocationsOfType(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	log.Debug().Msg("method invoked: collocations_of_type")
+	args, err := parseCollArgs(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	collType, err := request.RequireString("type")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !scoll.PredefinedSearch(collType).Validate() {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid collocation type: %s", collType)), nil
+	}
+	results, appErr := th.searcher.Collocations(
+		ctx,
+		args.datasetID,
+		args.collDBID,
+		args.word,
+		append(
+			args.options(),
+			scoll.WithPredefinedSearch(scoll.PredefinedSearch(collType)),
+			scoll.WithMaxAvgCollocateDist(1.499),
+		)...,
+	)
+	if !appErr.IsZero() {
+		return toolError(appErr), nil
+	}
+	return mcp.NewToolResultStructured(
+		CollocationsResult{
+			DatasetID: args.datasetID,
+			CollDBID:  args.collDBID,
+			Word:      args.word,
+			Items:     results,
+		},
+		th.formatCollocations(results),
+	), nil
+}
+
+func (th *toolHandler) dictionary(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	log.Debug().Msg("method invoked: dictionary")
+	args, err := parseWordArgs(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	results, appErr := th.searcher.Dictionary(ctx, args.datasetID, args.collDBID, args.word)
+	if !appErr.IsZero() {
+		return toolError(appErr), nil
+	}
+	var formattedRes strings.Builder
+	for i, res := range results {
+		formattedRes.WriteString(
+			fmt.Sprintf(
+				"%d. %s (%s), text type: %s, frequency: %v\n",
+				i+1, res.Lemma, res.PoS, res.TextType, res.Freq,
+			),
+		)
+	}
+	return mcp.NewToolResultStructured(
+		DictionaryResult{
+			DatasetID: args.datasetID,
+			CollDBID:  args.collDBID,
+			Word:      args.word,
+			Items:     results,
+		},
+		formattedRes.String(),
+	), nil
+}
+
+func (th *toolHandler) listDatasets(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	log.Debug().Msg("method invoked: list_datasets")
+	filter := request.GetString("dataset_id", "")
+	datasets, appErr := th.searcher.Datasets(ctx)
+	if !appErr.IsZero() {
+		return toolError(appErr), nil
+	}
+	if filter != "" {
+		datasets = slices.DeleteFunc(datasets, func(ds queries.DatasetInfo) bool { return ds.ID != filter })
+	}
+	var formattedRes strings.Builder
+	for _, ds := range datasets {
+		formattedRes.WriteString(fmt.Sprintf("dataset %s", ds.ID))
+		if ds.Description != "" {
+			formattedRes.WriteString(fmt.Sprintf(" - %s", ds.Description))
+		}
+		formattedRes.WriteString("\n")
+		for _, m := range ds.Models {
+			formattedRes.WriteString(
+				fmt.Sprintf(
+					"  model %s (supports PoS: %t, state: %s): %s\n",
+					m.ID, m.ContainsPoS, m.State, m.Description,
+				),
+			)
+		}
+		for _, db := range ds.CollDatabases {
+			formattedRes.WriteString(
+				fmt.Sprintf("  collocation database %s (default: %t): %s\n", db.ID, db.Default, db.Description))
+		}
+		features := make([]string, len(ds.Features))
+		for i, f := range ds.Features {
+			features[i] = string(f)
+		}
+		formattedRes.WriteString(fmt.Sprintf("  features: %s\n", strings.Join(features, ", ")))
+	}
+	return mcp.NewToolResultStructured(DatasetsResult{Datasets: datasets}, formattedRes.String()), nil
+}
+
+func registerTools(s *server.MCPServer, searcher GeneralSearcher) {
+	th := &toolHandler{searcher: searcher}
+
+	s.AddTool(
+		mcp.NewTool("similarly_used_words",
+			mcp.WithDescription("Find words that are similarly used in the dataset"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithOutputSchema[SimilarWordsResult](),
+			mcp.WithString("dataset_id",
+				mcp.Required(),
+				mcp.Description("The dataset ID to search in"),
+			),
+			mcp.WithString("model_id",
+				mcp.Required(),
+				mcp.Description("The model ID to use"),
+			),
+			mcp.WithString("pos_or_sfn",
+				mcp.Description("Part of speech or syntactic function"),
+			),
+			mcp.WithString("word",
+				mcp.Required(),
+				mcp.Description("The word to find similar usage for"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of results to return"),
+				mcp.DefaultNumber(dfltLimit),
+			),
+			mcp.WithNumber("min_score",
+				mcp.Description("Minimum similarity score threshold"),
+				mcp.DefaultNumber(dfltMinScore),
+			),
+		),
+		th.similarlyUsedWords,
+	)
+
+	s.AddTool(
+		mcp.NewTool("collocations",
+			mcp.WithDescription("Find syntactic collocations of a lemma in the dataset"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithOutputSchema[CollocationsResult](),
+			mcp.WithString("dataset_id",
+				mcp.Required(),
+				mcp.Description("The dataset ID to search in"),
+			),
+			mcp.WithString("word",
+				mcp.Required(),
+				mcp.Description("The lemma to find collocations for"),
+			),
+			mcp.WithString("pos",
+				mcp.Description("Universal Dependencies PoS tag of the lemma (e.g. NOUN, VERB, ADJ)"),
+			),
+			mcp.WithString("text_type",
+				mcp.Description("Text type to restrict the search to"),
+			),
+			mcp.WithString("coll_db_id",
+				mcp.Description("Collocation database ID (the dataset's default one is used if omitted)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of results to return"),
+				mcp.DefaultNumber(dfltLimit),
+			),
+			mcp.WithString("sort_by",
+				mcp.Description("Association measure to sort results by"),
+				mcp.Enum(sortingMeasures...),
+				mcp.DefaultString(dfltSortBy),
+			),
+		),
+		th.collocations,
+	)
+
+	s.AddTool(
+		mcp.NewTool("collocations_of_type",
+			mcp.WithDescription("Find collocations of a lemma in a predefined syntactic relation"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithOutputSchema[CollocationsResult](),
+			mcp.WithString("dataset_id",
+				mcp.Required(),
+				mcp.Description("The dataset ID to search in"),
+			),
+			mcp.WithString("type",
+				mcp.Required(),
+				mcp.Description("Type of the syntactic relation"),
+				mcp.Enum(predefinedSearchs...),
+			),
+			mcp.WithString("word",
+				mcp.Required(),
+				mcp.Description("The lemma to find collocations for"),
+			),
+			mcp.WithString("pos",
+				mcp.Description("Universal Dependencies PoS tag of the lemma (e.g. NOUN, VERB, ADJ)"),
+			),
+			mcp.WithString("text_type",
+				mcp.Description("Text type to restrict the search to"),
+			),
+			mcp.WithString("coll_db_id",
+				mcp.Description("Collocation database ID (the dataset's default one is used if omitted)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of results to return"),
+				mcp.DefaultNumber(dfltLimit),
+			),
+			mcp.WithString("sort_by",
+				mcp.Description("Association measure to sort results by"),
+				mcp.Enum(sortingMeasures...),
+				mcp.DefaultString(dfltSortBy),
+			),
+		),
+		th.collocationsOfType,
+	)
+
+	s.AddTool(
+		mcp.NewTool("dictionary",
+			mcp.WithDescription("Get parts of speech, text types and frequencies of a lemma in the dataset"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithOutputSchema[DictionaryResult](),
+			mcp.WithString("dataset_id",
+				mcp.Required(),
+				mcp.Description("The dataset ID to search in"),
+			),
+			mcp.WithString("word",
+				mcp.Required(),
+				mcp.Description("The lemma to look up"),
+			),
+			mcp.WithString("coll_db_id",
+				mcp.Description("Collocation database ID (the dataset's default one is used if omitted)"),
+			),
+		),
+		th.dictionary,
+	)
+
+	s.AddTool(
+		mcp.NewTool("list_datasets",
+			mcp.WithDescription("List available datasets along with their models, collocation databases and supported features"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithOutputSchema[DatasetsResult](),
+			mcp.WithString("dataset_id",
+				mcp.Description("Show only the specified dataset"),
+			),
+		),
+		th.listDatasets,
+	)
+}
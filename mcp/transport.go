@@ -0,0 +1,182 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	httpShutdownTimeout = 10 * time.Second
+)
+
+// HTTPTransport serves a single MCP server via both the streamable
+// HTTP transport (at basePath) and the legacy SSE transport
+// (at [basePath]/sse and [basePath]/message). This allows many
+// agent clients to share a single process (and loaded models).
+type HTTPTransport struct {
+	basePath   string
+	authn      *auth.Authenticator
+	streamable *server.StreamableHTTPServer
+	sse        *server.SSEServer
+
+	// streamsCtx is cancelled by CloseStreams
+	streamsCtx   context.Context
+	closeStreams context.CancelFunc
+}
+
+func (t *HTTPTransport) ssePath() string {
+	return path.Join(t.basePath, "sse")
+}
+
+// StreamPaths returns URL paths where HTTPTransport mounted
+// at basePath opens long-lived (GET) streams
+func StreamPaths(basePath string) []string {
+	basePath = path.Clean(basePath)
+	return []string{basePath, path.Join(basePath, "sse")}
+}
+
+func (t *HTTPTransport) messagePath() string {
+	return path.Join(t.basePath, "message")
+}
+
+// asStream prepares long-lived (streaming) responses - the server's
+// write timeout is removed and the stream ends once CloseStreams is called
+func (t *HTTPTransport) asStream(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+				log.Warn().Err(err).Msg("failed to disable write deadline for MCP stream")
+			}
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(t.streamsCtx, cancel)
+			defer stop()
+			r = r.WithContext(ctx)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// CloseStreams ends all the long-lived streams (SSE) so a server
+// shutdown does not have to wait for them. It is intended to be
+// registered via http.Server.RegisterOnShutdown.
+func (t *HTTPTransport) CloseStreams() {
+	t.closeStreams()
+}
+
+// authenticate attaches a principal matching the request's API key
+// to the request context. For an invalid key, false is returned.
+func (t *HTTPTransport) authenticate(r *http.Request) (*http.Request, bool) {
+	if t.authn == nil {
+		return r, true
+	}
+	principal, appErr := t.authn.Authenticate(auth.KeyFromRequest(r))
+	if !appErr.IsZero() {
+		return r, false
+	}
+	if principal == nil {
+		return r, true
+	}
+	return r.WithContext(auth.WithPrincipal(r.Context(), principal)), true
+}
+
+// ServeHTTP implements http.Handler. Tool and resource handlers get
+// the principal of the client via their contexts (see auth.FromContext).
+func (t *HTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, ok := t.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "invalid API key", http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Path {
+	case t.basePath:
+		t.asStream(t.streamable).ServeHTTP(w, r)
+	case t.ssePath(), t.messagePath():
+		t.asStream(t.sse).ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// MountGin registers all the MCP endpoints within a gin engine.
+// Optional middleware (e.g. rate limiting) runs before the transport.
+func (t *HTTPTransport) MountGin(engine *gin.Engine, middleware ...gin.HandlerFunc) {
+	handlers := append(slices.Clone(middleware), gin.WrapH(t))
+	engine.POST(t.basePath, handlers...)
+	engine.GET(t.basePath, handlers...)
+	engine.DELETE(t.basePath, handlers...)
+	engine.GET(t.ssePath(), handlers...)
+	engine.POST(t.messagePath(), handlers...)
+}
+
+// ListenAndServe runs a standalone HTTP server until the context
+// is cancelled
+func (t *HTTPTransport) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: t,
+	}
+	// long-lived streams (SSE) must end once we are asked to stop,
+	// otherwise the shutdown would wait for them to time out
+	srv.RegisterOnShutdown(t.CloseStreams)
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info().
+			Str("address", addr).
+			Str("streamablePath", t.basePath).
+			Str("ssePath", t.ssePath()).
+			Msg("starting MCP HTTP transport")
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NewHTTPTransport is a recommended factory function for HTTPTransport.
+// A nil authn means no authentication.
+func NewHTTPTransport(s *server.MCPServer, basePath string, authn *auth.Authenticator) *HTTPTransport {
+	ans := &HTTPTransport{basePath: path.Clean(basePath), authn: authn}
+	ans.streamsCtx, ans.closeStreams = context.WithCancel(context.Background())
+	ans.streamable = server.NewStreamableHTTPServer(s, server.WithEndpointPath(ans.basePath))
+	ans.sse = server.NewSSEServer(
+		s,
+		server.WithStaticBasePath(ans.basePath),
+		server.WithUseFullURLForMessageEndpoint(false),
+	)
+	return ans
+}
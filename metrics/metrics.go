@@ -0,0 +1,236 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides Prometheus metrics of wsserver.
+// All the collectors are registered in the default Prometheus
+// registry.
+package metrics
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/czcorpus/wsserver/model"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Path is a URL path the metrics are exposed at
+	Path = "/metrics"
+
+	Namespace = "wsserver"
+
+	// otherLabelValue replaces corpus and model IDs which are
+	// not configured so clients cannot blow up label cardinality
+	otherLabelValue = "other"
+
+	unmatchedRoute = "unmatched"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of processed HTTP requests",
+		},
+		[]string{"route", "method", "status", "corpus", "model"},
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests processing",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"route", "method", "status", "corpus", "model"},
+	)
+
+	requestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests being processed (long-lived streams excluded)",
+		},
+		[]string{"route"},
+	)
+
+	streamsOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "http_streams_open",
+			Help:      "Number of open long-lived HTTP streams (e.g. MCP SSE)",
+		},
+		[]string{"route"},
+	)
+
+	modelLoadDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "model_load_duration_seconds",
+			Help:      "Duration of word embeddings models loading",
+			Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+		},
+		[]string{"corpus", "model"},
+	)
+
+	modelsResident = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "models_resident",
+			Help:      "Whether a word embeddings model is loaded in memory (1) or not (0)",
+		},
+		[]string{"corpus", "model"},
+	)
+
+	collDBQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "colldb_query_duration_seconds",
+			Help:      "Duration of collocation database queries",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"operation", "corpus", "coll_db"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		requestsTotal,
+		requestDuration,
+		requestsInFlight,
+		streamsOpen,
+		modelLoadDuration,
+		modelsResident,
+		collDBQueryDuration,
+	)
+}
+
+// LabelFilter limits values of the corpus and model labels
+// to the configured corpora and models
+type LabelFilter struct {
+	corpora map[string]bool
+	models  map[string]bool
+}
+
+func (f *LabelFilter) corpus(v string) string {
+	if v == "" || f.corpora[v] {
+		return v
+	}
+	return otherLabelValue
+}
+
+func (f *LabelFilter) model(corpusID, modelID string) string {
+	if modelID == "" || f.models[corpusID+"/"+modelID] {
+		return modelID
+	}
+	return otherLabelValue
+}
+
+// NewLabelFilter is a recommended factory function for LabelFilter
+func NewLabelFilter(corpora []string, models []model.ModelConf) *LabelFilter {
+	ans := &LabelFilter{
+		corpora: make(map[string]bool),
+		models:  make(map[string]bool),
+	}
+	for _, c := range corpora {
+		ans.corpora[c] = true
+	}
+	for _, m := range models {
+		ans.corpora[m.Corpname] = true
+		ans.models[m.Corpname+"/"+m.ID] = true
+	}
+	return ans
+}
+
+// Middleware measures the number, duration and concurrency
+// of HTTP requests. To record final statuses, it must be
+// installed before any middleware writing error responses.
+// GET requests of streamRoutes (e.g. MCP SSE streams) may stay open
+// for hours so they are counted by a separate gauge and their
+// duration is not observed.
+func Middleware(filter *LabelFilter, streamRoutes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		isStream := ctx.Request.Method == http.MethodGet && slices.Contains(streamRoutes, route)
+		var inFlight prometheus.Gauge
+		if isStream {
+			inFlight = streamsOpen.WithLabelValues(route)
+
+		} else {
+			inFlight = requestsInFlight.WithLabelValues(route)
+		}
+		inFlight.Inc()
+		t0 := time.Now()
+
+		ctx.Next()
+
+		inFlight.Dec()
+		corpusID := ctx.Param("corpusId")
+		labels := []string{
+			route,
+			ctx.Request.Method,
+			strconv.Itoa(ctx.Writer.Status()),
+			filter.corpus(corpusID),
+			filter.model(corpusID, ctx.Param("modelId")),
+		}
+		requestsTotal.WithLabelValues(labels...).Inc()
+		if isStream {
+			return
+		}
+		requestDuration.WithLabelValues(labels...).Observe(time.Since(t0).Seconds())
+	}
+}
+
+// ObserveModelLoad records a model loading duration.
+// The function is intended as a model.Provider OnLoad listener.
+func ObserveModelLoad(conf *model.ModelConf, loadTime time.Duration) {
+	modelLoadDuration.WithLabelValues(conf.Corpname, conf.ID).Observe(loadTime.Seconds())
+}
+
+// ObserveCollDBQuery records a duration of a collocation database
+// operation started at t0
+func ObserveCollDBQuery(operation, corpusID, collDBID string, t0 time.Time) {
+	collDBQueryDuration.WithLabelValues(operation, corpusID, collDBID).Observe(time.Since(t0).Seconds())
+}
+
+// RegisterModelProvider exposes which of the provider's models
+// are resident in memory. All the configured models are reported
+// (not loaded ones with zero value). As it registers provider
+// listeners, it should be called before the provider starts
+// serving queries.
+func RegisterModelProvider(models *model.Provider) {
+	models.OnLoad(func(conf *model.ModelConf, _ time.Duration) {
+		modelsResident.WithLabelValues(conf.Corpname, conf.ID).Set(1)
+	})
+	models.OnUnload(func(conf *model.ModelConf) {
+		modelsResident.WithLabelValues(conf.Corpname, conf.ID).Set(0)
+	})
+	for _, corpusID := range models.Corpora() {
+		for _, status := range models.ListModelStatus(corpusID) {
+			if status.State != model.ModelStateLoaded {
+				modelsResident.WithLabelValues(corpusID, status.ID).Set(0)
+			}
+		}
+	}
+}
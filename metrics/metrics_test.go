@@ -0,0 +1,104 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/czcorpus/wsserver/model"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name         string
+		method       string
+		route        string
+		wantInFlight float64
+		wantStreams  float64
+		wantObserved bool
+	}{
+		{"MCP stream", http.MethodGet, "/mcp", 0, 1, false},
+		{"legacy MCP SSE stream", http.MethodGet, "/mcp/sse", 0, 1, false},
+		{"MCP message", http.MethodPost, "/mcp", 1, 0, true},
+		{"regular request", http.MethodGet, "/dataset/:corpusId", 1, 0, true},
+	}
+	for _, tt := range tests {
+		engine := gin.New()
+		engine.Use(Middleware(NewLabelFilter(nil, nil), "/mcp", "/mcp/sse"))
+		var inFlight, streams float64
+		engine.Handle(tt.method, tt.route, func(ctx *gin.Context) {
+			inFlight = testutil.ToFloat64(requestsInFlight.WithLabelValues(tt.route))
+			streams = testutil.ToFloat64(streamsOpen.WithLabelValues(tt.route))
+			ctx.Status(http.StatusOK)
+		})
+		target := tt.route
+		if target == "/dataset/:corpusId" {
+			target = "/dataset/syn2020"
+		}
+		observedBefore := testutil.CollectAndCount(requestDuration)
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, target, nil))
+		assert.Equal(t, tt.wantInFlight, inFlight, tt.name)
+		assert.Equal(t, tt.wantStreams, streams, tt.name)
+		assert.Equal(t, 0.0, testutil.ToFloat64(requestsInFlight.WithLabelValues(tt.route)), tt.name)
+		assert.Equal(t, 0.0, testutil.ToFloat64(streamsOpen.WithLabelValues(tt.route)), tt.name)
+		assert.Equal(t, tt.wantObserved, testutil.CollectAndCount(requestDuration) > observedBefore, tt.name)
+	}
+}
+
+func TestModelsResident(t *testing.T) {
+	dataDir := t.TempDir()
+	var data bytes.Buffer
+	data.WriteString("1 2\npes ")
+	binary.Write(&data, binary.LittleEndian, []float32{1, 0})
+	data.WriteString("\n")
+	if err := os.WriteFile(filepath.Join(dataDir, "m1.bin"), data.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	confs := []model.ModelConf{
+		{Corpname: "mtest", ID: "m1", Filename: "m1.bin", DataDir: "."},
+		{Corpname: "mtest", ID: "m2", Filename: "m2.bin", DataDir: "."},
+	}
+	provider := model.NewProvider(model.NewPathResolver(dataDir, nil), confs, 0)
+	RegisterModelProvider(provider)
+	resident := func() []float64 {
+		return []float64{
+			testutil.ToFloat64(modelsResident.WithLabelValues("mtest", "m1")),
+			testutil.ToFloat64(modelsResident.WithLabelValues("mtest", "m2")),
+		}
+	}
+	assert.Equal(t, []float64{0, 0}, resident())
+
+	infos, err := provider.ListModels("mtest", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, infos[0].Size)
+	assert.NotEmpty(t, infos[1].Error)
+	assert.Equal(t, []float64{1, 0}, resident())
+
+	assert.NoError(t, provider.Close(context.Background()))
+	assert.Equal(t, []float64{0, 0}, resident())
+}
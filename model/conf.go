@@ -16,14 +16,25 @@
 
 package model
 
-import "path/filepath"
-
+// ModelConf configures a single word2vec model.
+//
+// Filename can be absolute or relative to DataDir (if set) or
+// to `[global data dir]/[corpname]`. DataDir itself can be absolute
+// or relative to the global data directory. Filename may contain
+// glob patterns in which case the newest matching file is used
+// (see PathResolver).
+//
+// A model may reference a collocation database via CollDatabaseID.
+// The SyntaxDatabasePath is a legacy way of specifying the database
+// which implicitly defines a database with ID equal to Corpname.
 type ModelConf struct {
 	Corpname           string `json:"corpname"`
 	ID                 string `json:"id"`
 	Filename           string `json:"filename"`
+	DataDir            string `json:"dataDir"`
 	ContainsPoS        bool   `json:"containsPos"`
 	Description        string `json:"description"`
+	CollDatabaseID     string `json:"collDatabaseId"`
 	SyntaxDatabasePath string `json:"syntaxDatabasePath"`
 }
 
@@ -31,6 +42,33 @@ func (m *ModelConf) ModelKey() string {
 	return m.Corpname + ":" + m.ID
 }
 
-func (m *ModelConf) MkDataPath(root string) string {
-	return filepath.Join(root, m.Corpname, m.Filename)
+// CollDBID returns ID of the collocation database the model
+// is linked with (either explicitly or via the legacy
+// SyntaxDatabasePath). If there is no such database,
+// an empty string is returned.
+func (m *ModelConf) CollDBID() string {
+	if m.CollDatabaseID != "" {
+		return m.CollDatabaseID
+	}
+	if m.SyntaxDatabasePath != "" {
+		return m.Corpname
+	}
+	return ""
+}
+
+// CollDBConf configures a collocation (syntax) database. There can be
+// multiple databases for a single corpus (e.g. per corpus version or
+// per annotation scheme). Path can be absolute or relative to the global
+// data directory and it may contain glob patterns (see PathResolver).
+type CollDBConf struct {
+	ID          string `json:"id"`
+	Corpname    string `json:"corpname"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+
+	// Default marks the database used for the corpus in case
+	// no database is specified explicitly in a query. If no
+	// database of a corpus is marked as default, the first
+	// configured one is used.
+	Default bool `json:"default"`
 }
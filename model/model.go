@@ -17,17 +17,50 @@
 package model
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/sajari/word2vec"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/czcorpus/wsserver/model")
+
 var (
 	ErrModelNotFound     = errors.New("model not found")
 	ErrModelConfNotFound = errors.New("model configuration not found")
+	ErrModelLoading      = errors.New("model is being loaded")
+	ErrProviderClosed    = errors.New("model provider is closed")
+)
+
+// ModelLoadState describes whether a model is already
+// available in memory without forcing it to load
+type ModelLoadState string
+
+const (
+	ModelStateLoaded    ModelLoadState = "loaded"
+	ModelStateLoading   ModelLoadState = "loading"
+	ModelStateAvailable ModelLoadState = "available"
+	ModelStateMissing   ModelLoadState = "missing"
 )
 
+// ModelStatus is a lightweight description of a configured model.
+// Unlike ModelInfo, obtaining it never triggers model loading.
+type ModelStatus struct {
+	ID          string         `json:"id"`
+	Description string         `json:"description"`
+	ContainsPoS bool           `json:"containsPos"`
+	State       ModelLoadState `json:"state"`
+}
+
 type ModelInfo struct {
 	Name        string `json:"name"`
 	Size        int    `json:"size"`
@@ -39,11 +72,7 @@ type ModelInfo struct {
 // a file. If not or in case of an IO error,
 // false is returned.
 func isFile(path string) bool {
-	f, err := os.Open(path)
-	if err != nil {
-		return false
-	}
-	finfo, err := f.Stat()
+	finfo, err := os.Stat(path)
 	if err != nil {
 		return false
 	}
@@ -56,9 +85,44 @@ func isFile(path string) bool {
 // for multiple models. Please be aware though that each
 // model is typically quite memory consuming.
 type Provider struct {
-	dataDir string
-	models  map[string]*word2vec.Model
-	configs []ModelConf
+	paths    *PathResolver
+	models   map[string]*Vectors
+	versions map[string]string
+	loading  map[string]bool
+	configs  []ModelConf
+	onLoad   []func(conf *ModelConf, loadTime time.Duration)
+	onUnload []func(conf *ModelConf)
+	closed   bool
+	mu       sync.RWMutex
+
+	// loadCtx is cancelled once the provider is closed
+	// to stop pending model loads
+	loadCtx     context.Context
+	cancelLoads context.CancelFunc
+	loads       sync.WaitGroup
+
+	// scanSlots limits the number of concurrent similarity
+	// scans (nil means unlimited)
+	scanSlots chan struct{}
+}
+
+// OnLoad registers a function called each time a model
+// is successfully loaded. Listeners should be registered
+// before the provider starts serving queries.
+func (m *Provider) OnLoad(fn func(conf *ModelConf, loadTime time.Duration)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onLoad = append(m.onLoad, fn)
+}
+
+// OnUnload registers a function called each time a loaded
+// model is removed from memory (i.e. once the provider is closed).
+// Listeners should be registered before the provider starts
+// serving queries.
+func (m *Provider) OnUnload(fn func(conf *ModelConf)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onUnload = append(m.onUnload, fn)
 }
 
 func (m *Provider) FindModel(corpusName string, modelName string) (*ModelConf, error) {
@@ -70,31 +134,181 @@ func (m *Provider) FindModel(corpusName string, modelName string) (*ModelConf, e
 	return nil, ErrModelConfNotFound
 }
 
-func (m *Provider) access(conf *ModelConf) (*word2vec.Model, error) {
-	_, ok := m.models[conf.ModelKey()]
-	if !ok {
-		dataPath := conf.MkDataPath(m.dataDir)
-		if !isFile(dataPath) {
-			return nil, ErrModelNotFound
-		}
-		f, err := os.Open(dataPath)
-		if err != nil {
-			return nil, err
-		}
-		model, err := word2vec.FromReader(f)
-		if err != nil {
-			return nil, err
+// access returns a loaded model. If the model is not loaded yet,
+// it is loaded in background and the call waits for the load
+// (at most until the context is done - the load continues anyway
+// and an expired deadline is reported as ErrModelLoading).
+// Requests for a model which is being loaded by another request
+// fail with ErrModelLoading so they do not pile up waiting for
+// the (slow) load.
+func (m *Provider) access(ctx context.Context, conf *ModelConf) (*Vectors, error) {
+	key := conf.ModelKey()
+	m.mu.RLock()
+	model, ok := m.models[key]
+	m.mu.RUnlock()
+	if ok {
+		return model, nil
+	}
+	m.mu.Lock()
+	if model, ok := m.models[key]; ok {
+		m.mu.Unlock()
+		return model, nil
+	}
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrProviderClosed
+	}
+	if m.loading[key] {
+		m.mu.Unlock()
+		return nil, ErrModelLoading
+	}
+	m.loading[key] = true
+	m.loads.Add(1)
+	m.mu.Unlock()
+
+	type loadResult struct {
+		model *Vectors
+		err   error
+	}
+	done := make(chan loadResult, 1)
+	go func() {
+		defer m.loads.Done()
+		// the request only provides a parent span, it does not bound the load
+		model, err := m.loadAndRegister(context.WithoutCancel(ctx), conf)
+		done <- loadResult{model, err}
+	}()
+	select {
+	case res := <-done:
+		return res.model, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// the model will be available once the load finishes
+			return nil, ErrModelLoading
 		}
-		m.models[conf.ModelKey()] = model
+		return nil, ctx.Err()
 	}
-	return m.models[conf.ModelKey()], nil
 }
 
-func (m *Provider) Query(conf *ModelConf, word, pos string, limit int) ([]word2vec.Match, error) {
-	model, err := m.access(conf)
+// loadAndRegister loads a model and makes it available to queries
+func (m *Provider) loadAndRegister(ctx context.Context, conf *ModelConf) (*Vectors, error) {
+	key := conf.ModelKey()
+	_, span := tracer.Start(
+		ctx,
+		"model.load",
+		trace.WithAttributes(attribute.String("corpus", conf.Corpname), attribute.String("model", conf.ID)),
+	)
+	t0 := time.Now()
+	model, version, err := m.load(conf)
+	loadTime := time.Since(t0)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	m.mu.Lock()
+	delete(m.loading, key)
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrProviderClosed
+	}
+	if err != nil {
+		m.mu.Unlock()
 		return nil, err
 	}
+	m.models[key] = model
+	m.versions[key] = version
+	listeners := m.onLoad
+	m.mu.Unlock()
+	for _, fn := range listeners {
+		fn(conf, loadTime)
+	}
+	return model, nil
+}
+
+// load reads model data along with their version (see DataVersion)
+func (m *Provider) load(conf *ModelConf) (*Vectors, string, error) {
+	dataPath, err := m.paths.ModelPath(conf)
+	if errors.Is(err, ErrNoGlobMatch) {
+		return nil, "", ErrModelNotFound
+
+	} else if err != nil {
+		return nil, "", err
+	}
+	if !isFile(dataPath) {
+		return nil, "", ErrModelNotFound
+	}
+	version, err := DataVersion(dataPath)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(dataPath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	// loading is not bound to the request which triggered it,
+	// only closing the provider stops it
+	model, err := ReadVectors(m.loadCtx, f)
+	if m.loadCtx.Err() != nil {
+		return nil, "", ErrProviderClosed
+
+	} else if err != nil {
+		return nil, "", err
+	}
+	return model, version, nil
+}
+
+// DataVersion returns a version of the model's data. For a loaded
+// model, the version of the data file at the time of loading is
+// returned. The method never loads the model.
+func (m *Provider) DataVersion(conf *ModelConf) (string, error) {
+	m.mu.RLock()
+	version, ok := m.versions[conf.ModelKey()]
+	m.mu.RUnlock()
+	if ok {
+		return version, nil
+	}
+	dataPath, err := m.paths.ModelPath(conf)
+	if errors.Is(err, ErrNoGlobMatch) {
+		return "", ErrModelNotFound
+
+	} else if err != nil {
+		return "", err
+	}
+	return DataVersion(dataPath)
+}
+
+// Query searches for words most similar to the provided one.
+// The scan stops once the context is cancelled.
+func (m *Provider) Query(
+	ctx context.Context,
+	conf *ModelConf,
+	word, pos string,
+	limit int,
+) ([]word2vec.Match, error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"model.query",
+		trace.WithAttributes(
+			attribute.String("corpus", conf.Corpname),
+			attribute.String("model", conf.ID),
+			attribute.String("pos", pos),
+			attribute.Int("limit", limit),
+		),
+	)
+	defer span.End()
+	model, err := m.access(ctx, conf)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	release, err := m.AcquireScanSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	expr := word2vec.Expr{}
 	if conf.ContainsPoS {
 		expr.Add(1, word+"_"+pos)
@@ -102,17 +316,36 @@ func (m *Provider) Query(conf *ModelConf, word, pos string, limit int) ([]word2v
 	} else {
 		expr.Add(1, word)
 	}
-	return model.CosN(expr, limit+1)
+	return model.CosN(ctx, expr, limit+1)
+}
+
+// AcquireScanSlot waits for a free slot for an expensive scan
+// (a similarity scan or a collocation query). The returned function
+// must be called once the scan is finished. Once the context is done,
+// the waiting ends with the context's error.
+func (m *Provider) AcquireScanSlot(ctx context.Context) (func(), error) {
+	if m.scanSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case m.scanSlots <- struct{}{}:
+		return func() { <-m.scanSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
-func (m *Provider) ListModels(corpname string) ([]ModelInfo, error) {
+// ListModels provides information about models of a corpus accepted
+// by the accept function (nil accepts all the models). Please note that
+// all the listed models are loaded.
+func (m *Provider) ListModels(corpname string, accept func(conf *ModelConf) bool) ([]ModelInfo, error) {
 
 	ans := make([]ModelInfo, 0, len(m.configs))
 	for _, modelConf := range m.configs {
-		if modelConf.Corpname != corpname {
+		if modelConf.Corpname != corpname || accept != nil && !accept(&modelConf) {
 			continue
 		}
-		model, err := m.access(&modelConf)
+		model, err := m.access(context.Background(), &modelConf)
 		info := ModelInfo{
 			Name:        modelConf.ID,
 			Description: modelConf.Description,
@@ -130,11 +363,117 @@ func (m *Provider) ListModels(corpname string) ([]ModelInfo, error) {
 	return ans, nil
 }
 
-// NewProvider is a recommended factory function for Provider
-func NewProvider(dataDir string, configs []ModelConf) *Provider {
+// Corpora returns IDs of all the corpora referenced
+// by configured models (in the order of appearance)
+func (m *Provider) Corpora() []string {
+	ans := make([]string, 0, len(m.configs))
+	for _, modelConf := range m.configs {
+		if !slices.Contains(ans, modelConf.Corpname) {
+			ans = append(ans, modelConf.Corpname)
+		}
+	}
+	return ans
+}
+
+// Close cancels pending model loads, waits for them to stop (at most
+// until the context is done) and releases all the loaded models.
+// A closed provider fails all the queries with ErrProviderClosed.
+func (m *Provider) Close(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.cancelLoads()
+	done := make(chan struct{})
+	go func() {
+		m.loads.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("pending model loads did not stop in time: %w", ctx.Err())
+	}
+	m.mu.Lock()
+	unloaded := make([]*ModelConf, 0, len(m.models))
+	for i, conf := range m.configs {
+		if _, ok := m.models[conf.ModelKey()]; ok {
+			unloaded = append(unloaded, &m.configs[i])
+		}
+	}
+	clear(m.models)
+	clear(m.versions)
+	listeners := m.onUnload
+	m.mu.Unlock()
+	for _, conf := range unloaded {
+		for _, fn := range listeners {
+			fn(conf)
+		}
+	}
+	return nil
+}
+
+// NumLoaded returns the number of models resident in memory
+func (m *Provider) NumLoaded() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.models)
+}
+
+// LoadState tells whether the model is loaded, can be loaded
+// or its data file is missing. The method never loads the model.
+func (m *Provider) LoadState(conf *ModelConf) ModelLoadState {
+	m.mu.RLock()
+	_, ok := m.models[conf.ModelKey()]
+	loading := m.loading[conf.ModelKey()]
+	m.mu.RUnlock()
+	if ok {
+		return ModelStateLoaded
+	}
+	if loading {
+		return ModelStateLoading
+	}
+	if dataPath, err := m.paths.ModelPath(conf); err == nil && isFile(dataPath) {
+		return ModelStateAvailable
+	}
+	return ModelStateMissing
+}
+
+// ListModelStatus provides status of all the models configured
+// for a specified corpus. In contrast to ListModels, no model
+// is loaded by the method.
+func (m *Provider) ListModelStatus(corpname string) []ModelStatus {
+	ans := make([]ModelStatus, 0, len(m.configs))
+	for _, modelConf := range m.configs {
+		if modelConf.Corpname != corpname {
+			continue
+		}
+		ans = append(ans, ModelStatus{
+			ID:          modelConf.ID,
+			Description: modelConf.Description,
+			ContainsPoS: modelConf.ContainsPoS,
+			State:       m.LoadState(&modelConf),
+		})
+	}
+	return ans
+}
+
+// NewProvider is a recommended factory function for Provider.
+// Scans exceeding maxConcurrentScans wait for a free slot
+// (a non-positive value means no limit, see AcquireScanSlot).
+func NewProvider(paths *PathResolver, configs []ModelConf, maxConcurrentScans int) *Provider {
+	var scanSlots chan struct{}
+	if maxConcurrentScans > 0 {
+		scanSlots = make(chan struct{}, maxConcurrentScans)
+	}
+	loadCtx, cancelLoads := context.WithCancel(context.Background())
 	return &Provider{
-		dataDir: dataDir,
-		models:  make(map[string]*word2vec.Model),
-		configs: configs,
+		paths:       paths,
+		models:      make(map[string]*Vectors),
+		versions:    make(map[string]string),
+		loading:     make(map[string]bool),
+		configs:     configs,
+		scanSlots:   scanSlots,
+		loadCtx:     loadCtx,
+		cancelLoads: cancelLoads,
 	}
 }
@@ -0,0 +1,80 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderAcquireScanSlot(t *testing.T) {
+	provider := NewProvider(NewPathResolver(t.TempDir(), nil), nil, 1)
+	release, err := provider.AcquireScanSlot(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = provider.AcquireScanSlot(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release2, err := provider.AcquireScanSlot(context.Background())
+	assert.NoError(t, err)
+	release2()
+}
+
+func TestProviderUnlimitedScans(t *testing.T) {
+	provider := NewProvider(NewPathResolver(t.TempDir(), nil), nil, 0)
+	for i := 0; i < 3; i++ {
+		_, err := provider.AcquireScanSlot(context.Background())
+		assert.NoError(t, err)
+	}
+}
+
+func TestProviderListModelsLoadsOnlyAccepted(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dataDir, "syn2020"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	confs := []ModelConf{
+		{Corpname: "syn2020", ID: "public", Filename: "public.bin"},
+		{Corpname: "syn2020", ID: "restricted", Filename: "restricted.bin"},
+		{Corpname: "syn2015", ID: "other", Filename: "other.bin"},
+	}
+	for _, c := range confs[:2] {
+		err := os.WriteFile(filepath.Join(dataDir, c.Corpname, c.Filename), mkModelData(testWords, 3, true), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	provider := NewProvider(NewPathResolver(dataDir, nil), confs, 0)
+	infos, err := provider.ListModels("syn2020", func(conf *ModelConf) bool { return conf.ID == "public" })
+	assert.NoError(t, err)
+	assert.Equal(t, []ModelInfo{{Name: "public", Size: len(testWords)}}, infos)
+	assert.Equal(t, 1, provider.NumLoaded())
+	assert.Equal(t, ModelStateAvailable, provider.LoadState(&confs[1]))
+
+	infos, err = provider.ListModels("syn2020", nil)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 2)
+	assert.Equal(t, 2, provider.NumLoaded())
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrPathEscapesRoot = errors.New("path escapes allowed data roots")
+	ErrNoGlobMatch     = errors.New("no file matches the pattern")
+)
+
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// isWithin tests (lexically) whether path lies within root
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realPath resolves symbolic links of a path. Non-existing parts
+// of the path (e.g. a file to be created) are kept as they are.
+func realPath(path string) string {
+	if ans, err := filepath.EvalSymlinks(path); err == nil {
+		return ans
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(realPath(parent), filepath.Base(path))
+}
+
+// withinAllowedRoots tests whether path lies within one of the roots.
+// Symbolic links are resolved first so a link inside a root cannot
+// point outside of it.
+func withinAllowedRoots(roots []string, path string) bool {
+	real := realPath(path)
+	for _, root := range roots {
+		if isWithin(root, path) && isWithin(realPath(root), real) {
+			return true
+		}
+	}
+	return false
+}
+
+// newestMatch expands a glob pattern and returns the matching
+// path with the latest modification time
+func newestMatch(pattern string) (string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid path pattern %s: %w", pattern, err)
+	}
+	var ans string
+	var ansInfo os.FileInfo
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if ansInfo == nil || info.ModTime().After(ansInfo.ModTime()) {
+			ans = m
+			ansInfo = info
+		}
+	}
+	if ans == "" {
+		return "", fmt.Errorf("%w: %s", ErrNoGlobMatch, pattern)
+	}
+	return ans, nil
+}
+
+// PathResolver provides a consistent way of resolving data paths
+// (model files, collocation databases) from configuration:
+//
+//   - absolute paths are used as they are,
+//   - relative paths are resolved against a base directory
+//     (which itself is relative to the global data directory),
+//   - paths containing glob patterns (e.g. `model-v*.bin`) resolve
+//     to the matching file with the latest modification time.
+//
+// Relative paths must not escape their base directory (lexically,
+// symbolic links within the data directory are allowed). If allowed
+// roots are configured, every resolved path (with symbolic links
+// resolved) must lie within one of them.
+type PathResolver struct {
+	dataDir      string
+	allowedRoots []string
+}
+
+// Resolve converts a configured path into an actual filesystem path.
+// The base argument is a directory relative paths are resolved against.
+// If empty, the global data directory is used.
+func (r *PathResolver) Resolve(base, path string) (string, error) {
+	if base == "" {
+		base = r.dataDir
+
+	} else if !filepath.IsAbs(base) {
+		base = filepath.Join(r.dataDir, base)
+	}
+	ans := filepath.Clean(path)
+	if !filepath.IsAbs(ans) {
+		ans = filepath.Join(base, ans)
+		if !isWithin(base, ans) {
+			return "", fmt.Errorf("%w: %s (relative to %s)", ErrPathEscapesRoot, path, base)
+		}
+	}
+	if isGlob(ans) {
+		var err error
+		ans, err = newestMatch(ans)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(r.allowedRoots) > 0 && !withinAllowedRoots(r.allowedRoots, ans) {
+		return "", fmt.Errorf("%w: %s", ErrPathEscapesRoot, ans)
+	}
+	return ans, nil
+}
+
+// ModelPath resolves path of a model data file. Relative file names
+// are resolved against the model's dataDir or, if not set, against
+// the `[global data dir]/[corpname]` directory.
+func (r *PathResolver) ModelPath(conf *ModelConf) (string, error) {
+	base := conf.DataDir
+	if base == "" {
+		base = conf.Corpname
+	}
+	return r.Resolve(base, conf.Filename)
+}
+
+// CollDBPath resolves path of a collocation (syntax) database.
+// Relative paths are resolved against the global data directory.
+func (r *PathResolver) CollDBPath(conf *CollDBConf) (string, error) {
+	return r.Resolve("", conf.Path)
+}
+
+// NewPathResolver is a recommended factory function for PathResolver.
+func NewPathResolver(dataDir string, allowedRoots []string) *PathResolver {
+	roots := make([]string, len(allowedRoots))
+	for i, root := range allowedRoots {
+		roots[i] = filepath.Clean(root)
+	}
+	return &PathResolver{
+		dataDir:      filepath.Clean(dataDir),
+		allowedRoots: roots,
+	}
+}
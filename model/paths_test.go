@@ -0,0 +1,255 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathResolverResolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		dataDir      string
+		allowedRoots []string
+		base         string
+		path         string
+		want         string
+		wantErr      error
+	}{
+		{
+			name:    "relative to data dir",
+			dataDir: "/data",
+			path:    "syn2020/model.bin",
+			want:    "/data/syn2020/model.bin",
+		},
+		{
+			name:    "relative to base",
+			dataDir: "/data",
+			base:    "syn2020",
+			path:    "model.bin",
+			want:    "/data/syn2020/model.bin",
+		},
+		{
+			name:    "absolute base",
+			dataDir: "/data",
+			base:    "/models",
+			path:    "model.bin",
+			want:    "/models/model.bin",
+		},
+		{
+			name:    "absolute path",
+			dataDir: "/data",
+			base:    "syn2020",
+			path:    "/other/model.bin",
+			want:    "/other/model.bin",
+		},
+		{
+			name:    "path cleaned within base",
+			dataDir: "/data",
+			base:    "syn2020",
+			path:    "sub/../model.bin",
+			want:    "/data/syn2020/model.bin",
+		},
+		{
+			name:    "relative path escaping base",
+			dataDir: "/data",
+			base:    "syn2020",
+			path:    "../syn2015/model.bin",
+			wantErr: ErrPathEscapesRoot,
+		},
+		{
+			name:    "file name starting with dots",
+			dataDir: "/data",
+			path:    "..model.bin",
+			want:    "/data/..model.bin",
+		},
+		{
+			name:         "within allowed roots",
+			dataDir:      "/data",
+			allowedRoots: []string{"/models", "/data/"},
+			path:         "model.bin",
+			want:         "/data/model.bin",
+		},
+		{
+			name:         "absolute path outside allowed roots",
+			dataDir:      "/data",
+			allowedRoots: []string{"/data"},
+			path:         "/etc/passwd",
+			wantErr:      ErrPathEscapesRoot,
+		},
+		{
+			name:         "root prefix is not enough",
+			dataDir:      "/data",
+			allowedRoots: []string{"/data"},
+			path:         "/data2/model.bin",
+			wantErr:      ErrPathEscapesRoot,
+		},
+	}
+	for _, tt := range tests {
+		r := NewPathResolver(tt.dataDir, tt.allowedRoots)
+		ans, err := r.Resolve(tt.base, tt.path)
+		if tt.wantErr != nil {
+			assert.ErrorIs(t, err, tt.wantErr, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, ans, tt.name)
+	}
+}
+
+func TestPathResolverGlob(t *testing.T) {
+	dataDir := t.TempDir()
+	modelDir := filepath.Join(dataDir, "syn2020")
+	if err := os.Mkdir(modelDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i, name := range []string{"model-v1.bin", "model-v3.bin", "model-v2.bin"} {
+		path := filepath.Join(modelDir, name)
+		if err := os.WriteFile(path, []byte("0 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(time.Duration(i-10) * time.Minute)
+		if name == "model-v3.bin" {
+			mtime = now
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewPathResolver(dataDir, nil)
+
+	ans, err := r.ModelPath(&ModelConf{Corpname: "syn2020", Filename: "model-v*.bin"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(modelDir, "model-v3.bin"), ans)
+
+	_, err = r.ModelPath(&ModelConf{Corpname: "syn2020", Filename: "other-*.bin"})
+	assert.ErrorIs(t, err, ErrNoGlobMatch)
+
+	_, err = r.ModelPath(&ModelConf{Corpname: "syn2020", Filename: "../*/model-v*.bin"})
+	assert.ErrorIs(t, err, ErrPathEscapesRoot)
+}
+
+func TestPathResolverSymlinks(t *testing.T) {
+	dataDir := t.TempDir()
+	outsideDir := t.TempDir()
+	modelDir := filepath.Join(dataDir, "syn2020")
+	if err := os.Mkdir(modelDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{modelDir, outsideDir} {
+		if err := os.WriteFile(filepath.Join(dir, "model.bin"), []byte("0 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"inside.bin":  filepath.Join(modelDir, "model.bin"),
+		"outside.bin": filepath.Join(outsideDir, "model.bin"),
+		"outside":     outsideDir,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(modelDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	linkedRoot := filepath.Join(t.TempDir(), "data")
+	if err := os.Symlink(dataDir, linkedRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		allowedRoots []string
+		path         string
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "symlink within allowed root",
+			allowedRoots: []string{dataDir},
+			path:         "inside.bin",
+			want:         filepath.Join(modelDir, "inside.bin"),
+		},
+		{
+			name:         "file symlink escaping allowed root",
+			allowedRoots: []string{dataDir},
+			path:         "outside.bin",
+			wantErr:      ErrPathEscapesRoot,
+		},
+		{
+			name:         "directory symlink escaping allowed root",
+			allowedRoots: []string{dataDir},
+			path:         "outside/model.bin",
+			wantErr:      ErrPathEscapesRoot,
+		},
+		{
+			name:         "glob matching escaping symlink",
+			allowedRoots: []string{dataDir},
+			path:         "outside*.bin",
+			wantErr:      ErrPathEscapesRoot,
+		},
+		{
+			name:         "non-existing file behind escaping symlink",
+			allowedRoots: []string{dataDir},
+			path:         "outside/missing.bin",
+			wantErr:      ErrPathEscapesRoot,
+		},
+		{
+			name: "symlink escaping without allowed roots",
+			path: "outside.bin",
+			want: filepath.Join(modelDir, "outside.bin"),
+		},
+	}
+	for _, tt := range tests {
+		r := NewPathResolver(dataDir, tt.allowedRoots)
+		ans, err := r.Resolve("syn2020", tt.path)
+		if tt.wantErr != nil {
+			assert.ErrorIs(t, err, tt.wantErr, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, ans, tt.name)
+	}
+
+	// allowed roots can be symlinks themselves
+	r := NewPathResolver(linkedRoot, []string{linkedRoot})
+	ans, err := r.Resolve("syn2020", "model.bin")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(linkedRoot, "syn2020", "model.bin"), ans)
+	_, err = r.Resolve("syn2020", "outside.bin")
+	assert.ErrorIs(t, err, ErrPathEscapesRoot)
+}
+
+func TestPathResolverModelAndCollDBPath(t *testing.T) {
+	r := NewPathResolver("/data", nil)
+	ans, err := r.ModelPath(&ModelConf{Corpname: "syn2020", Filename: "model.bin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/syn2020/model.bin", ans)
+
+	ans, err = r.ModelPath(&ModelConf{Corpname: "syn2020", DataDir: "w2v", Filename: "model.bin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/w2v/model.bin", ans)
+
+	ans, err = r.CollDBPath(&CollDBConf{Path: "syn2020-coll"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/syn2020-coll", ans)
+}
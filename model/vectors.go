@@ -0,0 +1,149 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sajari/word2vec"
+)
+
+// ctxCheckInterval specifies how many words are processed between
+// two checks of a context cancellation
+const ctxCheckInterval = 4096
+
+// Vectors is a word2vec model (in the original binary format)
+// supporting cancellation of loading and similarity scans.
+// Unlike word2vec.Model, words are stored in a slice so a scan
+// can be interrupted.
+type Vectors struct {
+	dim   int
+	words []string
+	vecs  []word2vec.Vector
+	index map[string]int
+}
+
+// Size returns the number of words in the model
+func (m *Vectors) Size() int {
+	return len(m.words)
+}
+
+// Eval creates a normalised vector from a weighted sum
+// of the expression words' vectors
+func (m *Vectors) Eval(expr word2vec.Expr) (word2vec.Vector, error) {
+	v := word2vec.Vector(make([]float32, m.dim))
+	for w, c := range expr {
+		idx, ok := m.index[w]
+		if !ok {
+			return nil, &word2vec.NotFoundError{Word: w}
+		}
+		v.Add(c, m.vecs[idx])
+	}
+	v.Normalise()
+	return v, nil
+}
+
+// CosN finds n words most similar to the expression. The scan
+// is stopped once the context is cancelled.
+func (m *Vectors) CosN(ctx context.Context, expr word2vec.Expr, n int) ([]word2vec.Match, error) {
+	if n <= 0 {
+		return []word2vec.Match{}, nil
+	}
+	v, err := m.Eval(expr)
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]word2vec.Match, n)
+	for i, u := range m.vecs {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		p := word2vec.Match{Word: m.words[i], Score: v.Dot(u)}
+		if ans[n-1].Score > p.Score {
+			continue
+		}
+		ans[n-1] = p
+		for j := n - 2; j >= 0; j-- {
+			if ans[j].Score > p.Score {
+				break
+			}
+			ans[j], ans[j+1] = p, ans[j]
+		}
+	}
+	return ans, nil
+}
+
+// ReadVectors reads a binary word2vec model. The loading can be
+// stopped by cancelling the context.
+func ReadVectors(ctx context.Context, r io.Reader) (*Vectors, error) {
+	br := bufio.NewReader(r)
+	var size, dim int
+	n, err := fmt.Fscanln(br, &size, &dim)
+	if err != nil {
+		return nil, err
+	}
+	if n != 2 || size < 0 || dim <= 0 {
+		return nil, fmt.Errorf("could not extract size/dim from binary model data")
+	}
+	m := &Vectors{
+		dim:   dim,
+		words: make([]string, 0, size),
+		vecs:  make([]word2vec.Vector, 0, size),
+		index: make(map[string]int, size),
+	}
+	raw := make([]float32, size*dim)
+	for i := 0; i < size; i++ {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		w, err := br.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+		w = w[:len(w)-1]
+		v := word2vec.Vector(raw[dim*i : dim*(i+1)])
+		if err := binary.Read(br, binary.LittleEndian, v); err != nil {
+			return nil, err
+		}
+		v.Normalise()
+		m.index[w] = len(m.words)
+		m.words = append(m.words, w)
+		m.vecs = append(m.vecs, v)
+
+		b, err := br.ReadByte()
+		if err != nil {
+			if i == size-1 && err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if b != '\n' {
+			if err := br.UnreadByte(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
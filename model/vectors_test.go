@@ -0,0 +1,174 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/sajari/word2vec"
+	"github.com/stretchr/testify/assert"
+)
+
+type testWord struct {
+	word string
+	vec  []float32
+}
+
+// mkModelData creates a binary word2vec model. Vectors are
+// optionally followed by a newline (both variants exist in the wild).
+func mkModelData(words []testWord, dim int, newlines bool) []byte {
+	var buff bytes.Buffer
+	fmt.Fprintf(&buff, "%d %d\n", len(words), dim)
+	for _, w := range words {
+		buff.WriteString(w.word + " ")
+		binary.Write(&buff, binary.LittleEndian, w.vec)
+		if newlines {
+			buff.WriteByte('\n')
+		}
+	}
+	return buff.Bytes()
+}
+
+var testWords = []testWord{
+	{"dog", []float32{1, 0, 0}},
+	{"puppy", []float32{0.9, 0.1, 0}},
+	{"cat", []float32{0.6, 0.8, 0}},
+	{"car", []float32{0, 0, 1}},
+	{"anti-dog", []float32{-1, 0, 0}},
+}
+
+func TestReadVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantSize int
+		wantErr  bool
+	}{
+		{
+			name:     "vectors followed by newlines",
+			data:     mkModelData(testWords, 3, true),
+			wantSize: len(testWords),
+		},
+		{
+			name:     "vectors without newlines",
+			data:     mkModelData(testWords, 3, false),
+			wantSize: len(testWords),
+		},
+		{
+			name:     "empty model",
+			data:     []byte("0 3\n"),
+			wantSize: 0,
+		},
+		{
+			name:    "invalid header",
+			data:    []byte("five three\n"),
+			wantErr: true,
+		},
+		{
+			name:    "zero dimension",
+			data:    []byte("1 0\n"),
+			wantErr: true,
+		},
+		{
+			name:    "truncated data",
+			data:    mkModelData(testWords, 3, true)[:30],
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		m, err := ReadVectors(context.Background(), bytes.NewReader(tt.data))
+		if tt.wantErr {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.wantSize, m.Size(), tt.name)
+	}
+}
+
+func TestReadVectorsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ReadVectors(ctx, bytes.NewReader(mkModelData(testWords, 3, true)))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestVectorsCosN(t *testing.T) {
+	m, err := ReadVectors(context.Background(), bytes.NewReader(mkModelData(testWords, 3, true)))
+	assert.NoError(t, err)
+	tests := []struct {
+		name      string
+		expr      word2vec.Expr
+		n         int
+		wantWords []string
+		wantErr   bool
+	}{
+		{
+			name:      "most similar words first",
+			expr:      word2vec.Expr{"dog": 1},
+			n:         3,
+			wantWords: []string{"dog", "puppy", "cat"},
+		},
+		{
+			name:      "weighted expression",
+			expr:      word2vec.Expr{"dog": 1, "car": 2},
+			n:         2,
+			wantWords: []string{"car", "dog"},
+		},
+		{
+			name:      "zero results",
+			expr:      word2vec.Expr{"dog": 1},
+			n:         0,
+			wantWords: []string{},
+		},
+		{
+			name:    "unknown word",
+			expr:    word2vec.Expr{"wolf": 1},
+			n:       3,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		matches, err := m.CosN(context.Background(), tt.expr, tt.n)
+		if tt.wantErr {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		words := make([]string, len(matches))
+		for i, match := range matches {
+			words[i] = match.Word
+		}
+		assert.Equal(t, tt.wantWords, words, tt.name)
+	}
+	matches, err := m.CosN(context.Background(), word2vec.Expr{"dog": 1}, 1)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0, matches[0].Score, 1e-6)
+}
+
+func TestVectorsCosNCancelled(t *testing.T) {
+	m, err := ReadVectors(context.Background(), bytes.NewReader(mkModelData(testWords, 3, true)))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.CosN(ctx, word2vec.Expr{"dog": 1}, 3)
+	assert.ErrorIs(t, err, context.Canceled)
+}
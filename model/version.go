@@ -0,0 +1,59 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DataVersion identifies a version of a data file or directory
+// based on modification time and size. For a directory (e.g. a
+// collocation database), the latest modification time and the total
+// size of all the contained files are used.
+func DataVersion(path string) (string, error) {
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !finfo.IsDir() {
+		return fmt.Sprintf("%x-%x", finfo.ModTime().UnixNano(), finfo.Size()), nil
+	}
+	var latest, size, numFiles int64
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		latest = max(latest, info.ModTime().UnixNano())
+		size += info.Size()
+		numFiles++
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x-%x-%x", latest, size, numFiles), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/czcorpus/wsserver/config"
+)
+
+const (
+	// unixSocketPerm allows access to the socket for the owner
+	// and the group (e.g. a reverse proxy)
+	unixSocketPerm = 0660
+
+	socketProbeTimeout = time.Second
+)
+
+// ListenUnix creates a Unix domain socket listener. A stale socket
+// file (e.g. left by a crashed process) is replaced, a socket used by
+// a running process is reported as an error.
+func ListenUnix(path string) (net.Listener, error) {
+	if finfo, err := os.Stat(path); err == nil {
+		if finfo.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("cannot listen on %s: not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, socketProbeTimeout); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot listen on %s: socket in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketPerm); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// ServerProtocols returns HTTP protocols a server should accept.
+// HTTP/2 over TLS is always enabled, unencrypted HTTP/2 only
+// if enableH2C is true.
+func ServerProtocols(enableH2C bool) *http.Protocols {
+	ans := new(http.Protocols)
+	ans.SetHTTP1(true)
+	ans.SetHTTP2(true)
+	ans.SetUnencryptedHTTP2(enableH2C)
+	return ans
+}
+
+// NewClientTransport creates an HTTP transport for requests to wsserver
+// based on the MCP configuration (client TLS, Unix socket).
+func NewClientTransport(conf config.MCPConfig) (*http.Transport, error) {
+	tlsConf, err := NewClientTLSConfig(conf.ClientTLS)
+	if err != nil {
+		return nil, err
+	}
+	ans := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConf != nil {
+		ans.TLSClientConfig = tlsConf
+	}
+	if conf.ServerSocket != "" {
+		socket := conf.ServerSocket
+		dialer := &net.Dialer{}
+		ans.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		ans.Proxy = nil
+	}
+	return ans, nil
+}
+
+// IsServerClosed tells whether a serving error is caused
+// by a regular server shutdown
+func IsServerClosed(err error) bool {
+	return errors.Is(err, http.ErrServerClosed) || errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package netutil provides TLS configuration (with certificate
+// reloading), listeners and client transports for wsserver
+// and its internal consumers.
+package netutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/rs/zerolog/log"
+)
+
+// loadCertPool loads PEM encoded certificates into a pool.
+// If withSystem is true, the pool extends the system one.
+func loadCertPool(path string, withSystem bool) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if withSystem {
+		if sysPool, err := x509.SystemCertPool(); err == nil {
+			pool = sysPool
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no valid CA certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// certReloader keeps a server TLS configuration up to date with
+// the certificate, key and client CA files
+type certReloader struct {
+	conf    config.TLSConf
+	current atomic.Pointer[tls.Config]
+
+	// filesVersion identifies the versions of the files
+	// the current configuration was loaded from
+	filesVersion string
+}
+
+func (r *certReloader) files() []string {
+	ans := []string{r.conf.CertFile, r.conf.KeyFile}
+	if r.conf.ClientCAFile != "" {
+		ans = append(ans, r.conf.ClientCAFile)
+	}
+	return ans
+}
+
+func (r *certReloader) version() (string, error) {
+	versions := make([]string, 0, 3)
+	for _, f := range r.files() {
+		v, err := model.DataVersion(f)
+		if err != nil {
+			return "", err
+		}
+		versions = append(versions, v)
+	}
+	return strings.Join(versions, ";"), nil
+}
+
+func (r *certReloader) load() error {
+	version, err := r.version()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	tlsConf := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.conf.ClientCAFile != "" {
+		tlsConf.ClientCAs, err = loadCertPool(r.conf.ClientCAFile, false)
+		if err != nil {
+			return err
+		}
+		tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.conf.RequireClientCert {
+			tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	r.current.Store(tlsConf)
+	r.filesVersion = version
+	return nil
+}
+
+// watch reloads the configuration once any of the files changes.
+// In case the new files are invalid (e.g. a certificate has been
+// replaced but the key not yet), the previous configuration is kept.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			version, err := r.version()
+			if err != nil {
+				log.Error().Err(err).Msg("failed to check TLS files")
+				continue
+			}
+			if version == r.filesVersion {
+				continue
+			}
+			if err := r.load(); err != nil {
+				log.Error().Err(err).Msg("failed to reload TLS files, keeping the previous ones")
+				continue
+			}
+			log.Info().Str("certFile", r.conf.CertFile).Msg("TLS certificate reloaded")
+		}
+	}
+}
+
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return r.current.Load(), nil
+}
+
+// NewServerTLSConfig creates a server TLS configuration which follows
+// changes of the configured files until the context is cancelled.
+func NewServerTLSConfig(ctx context.Context, conf config.TLSConf) (*tls.Config, error) {
+	reloader := &certReloader{conf: conf}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	if conf.ReloadIntervalSecs > 0 {
+		go reloader.watch(ctx, time.Duration(conf.ReloadIntervalSecs)*time.Second)
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: reloader.getConfigForClient,
+		// the server configures HTTP/2 based on the base config (also
+		// in case the plain Serve is called first, e.g. for a Unix socket)
+		NextProtos: []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// NewClientTLSConfig creates a TLS configuration for connections
+// to wsserver. For an empty configuration, nil is returned which
+// means the defaults apply.
+func NewClientTLSConfig(conf config.ClientTLSConf) (*tls.Config, error) {
+	if conf.CertFile == "" && conf.CAFile == "" {
+		return nil, nil
+	}
+	ans := &tls.Config{MinVersion: tls.VersionTLS12}
+	if conf.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		ans.Certificates = []tls.Certificate{cert}
+	}
+	if conf.CAFile != "" {
+		pool, err := loadCertPool(conf.CAFile, true)
+		if err != nil {
+			return nil, err
+		}
+		ans.RootCAs = pool
+	}
+	return ans, nil
+}
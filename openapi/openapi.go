@@ -0,0 +1,225 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi provides a minimal OpenAPI 3.1 model used
+// to describe (and validate requests of) the wsserver REST API.
+// Routes are defined once and both the gin handlers and the
+// OpenAPI document are derived from them.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/invopop/jsonschema"
+)
+
+const (
+	Version = "3.1.0"
+
+	ParamInPath  = "path"
+	ParamInQuery = "query"
+
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+
+	// ErrorSchemaName is a name of a component schema describing
+	// error responses. If defined, it is used for automatically
+	// added responses to invalid requests.
+	ErrorSchemaName = "ErrorResponse"
+)
+
+var ginParamRegexp = regexp.MustCompile(`:(\w+)`)
+
+// Schema is a JSON schema (OpenAPI 3.1 uses JSON Schema 2020-12)
+type Schema = jsonschema.Schema
+
+// Param describes a path or query parameter. Only scalar
+// parameters are supported.
+type Param struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Param             `json:"parameters,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// PathItem maps lowercase HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Document is the root object of an OpenAPI specification
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Route binds an API path (in the gin notation) to its handler
+// and OpenAPI description
+type Route struct {
+	Method    string
+	Path      string
+	Handler   gin.HandlerFunc
+	Operation Operation
+}
+
+// OpenAPIPath converts gin path parameters (`:name`) into
+// the OpenAPI notation (`{name}`)
+func (r Route) OpenAPIPath() string {
+	return ginParamRegexp.ReplaceAllString(r.Path, "{$1}")
+}
+
+// SchemaOf creates an inlined JSON schema of a Go value's type
+func SchemaOf(v any) *Schema {
+	reflector := jsonschema.Reflector{
+		Anonymous:                 true,
+		DoNotReference:            true,
+		AllowAdditionalProperties: true,
+	}
+	ans := reflector.Reflect(v)
+	ans.Version = ""
+	return ans
+}
+
+// Ref creates a reference to a schema defined in components
+func Ref(schemaName string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + schemaName}
+}
+
+// ArrayOf creates a schema of an array with the specified items
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// JSONResponse describes a JSON response with the specified schema
+func JSONResponse(description string, schema *Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// PathParam creates a required string path parameter
+func PathParam(name, description string, enum ...string) Param {
+	schema := &Schema{Type: TypeString}
+	for _, v := range enum {
+		schema.Enum = append(schema.Enum, v)
+	}
+	return Param{
+		Name:        name,
+		In:          ParamInPath,
+		Description: description,
+		Required:    true,
+		Schema:      schema,
+	}
+}
+
+// QueryParam creates an optional query parameter
+func QueryParam(name, description string, schema *Schema) Param {
+	return Param{
+		Name:        name,
+		In:          ParamInQuery,
+		Description: description,
+		Schema:      schema,
+	}
+}
+
+// NewDocument creates an OpenAPI document describing the routes
+// as served under the basePath. The schemas are published
+// as reusable components.
+func NewDocument(
+	info Info,
+	basePath string,
+	routes []Route,
+	schemas map[string]*Schema,
+) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Servers:    []Server{{URL: basePath}},
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: schemas},
+	}
+	for _, route := range routes {
+		path := route.OpenAPIPath()
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		op := route.Operation
+		if _, ok := op.Responses[strconv.Itoa(http.StatusBadRequest)]; !ok && len(op.Parameters) > 0 {
+			responses := make(map[string]Response, len(op.Responses)+1)
+			for k, v := range op.Responses {
+				responses[k] = v
+			}
+			resp := Response{Description: "invalid request parameters"}
+			if _, ok := schemas[ErrorSchemaName]; ok {
+				resp = JSONResponse(resp.Description, Ref(ErrorSchemaName))
+			}
+			responses[strconv.Itoa(http.StatusBadRequest)] = resp
+			op.Responses = responses
+		}
+		item[strings.ToLower(route.Method)] = &op
+	}
+	return doc
+}
+
+// RegisterRoutes registers the routes along with request validation
+// middleware derived from their parameter descriptions
+func RegisterRoutes(group gin.IRoutes, routes []Route) {
+	for _, route := range routes {
+		group.Handle(route.Method, route.Path, ValidateParams(route.Operation.Parameters), route.Handler)
+	}
+}
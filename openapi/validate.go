@@ -0,0 +1,106 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/czcorpus/wsserver/core"
+	"github.com/gin-gonic/gin"
+)
+
+// validateValue checks a raw parameter value against a (scalar) schema
+func validateValue(value string, schema *Schema) error {
+	if schema == nil {
+		return nil
+	}
+	var numVal float64
+	switch schema.Type {
+	case TypeInteger:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		numVal = float64(v)
+	case TypeNumber:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		numVal = v
+	}
+	if schema.Minimum != "" {
+		if limit, err := schema.Minimum.Float64(); err == nil && numVal < limit {
+			return fmt.Errorf("value must be >= %s", schema.Minimum)
+		}
+	}
+	if schema.Maximum != "" {
+		if limit, err := schema.Maximum.Float64(); err == nil && numVal > limit {
+			return fmt.Errorf("value must be <= %s", schema.Maximum)
+		}
+	}
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, any(value)) {
+		return fmt.Errorf("value must be one of %v", schema.Enum)
+	}
+	return nil
+}
+
+// ValidateParams creates a middleware validating path and query
+// parameters of a request. Invalid requests are aborted with
+// a core.AppError (of the ErrorTypeInvalidArguments type)
+// registered in the request context.
+func ValidateParams(params []Param) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, param := range params {
+			var value string
+			var found bool
+			switch param.In {
+			case ParamInPath:
+				value = ctx.Param(param.Name)
+				found = value != ""
+			case ParamInQuery:
+				value, found = ctx.GetQuery(param.Name)
+			default:
+				continue
+			}
+			if !found {
+				if param.Required {
+					ctx.Error(core.NewAppError(
+						fmt.Sprintf("missing required parameter '%s'", param.Name),
+						core.ErrorTypeInvalidArguments,
+						nil,
+					).WithParam(param.Name))
+					ctx.Abort()
+					return
+				}
+				continue
+			}
+			if err := validateValue(value, param.Schema); err != nil {
+				ctx.Error(core.NewAppError(
+					fmt.Sprintf("invalid value of '%s'", param.Name),
+					core.ErrorTypeInvalidArguments,
+					err,
+				).WithParam(param.Name))
+				ctx.Abort()
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/czcorpus/wsserver/core"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateValue(t *testing.T) {
+	intSchema := &Schema{Type: TypeInteger, Minimum: json.Number("1"), Maximum: json.Number("100")}
+	numSchema := &Schema{Type: TypeNumber, Minimum: json.Number("0")}
+	enumSchema := &Schema{Type: TypeString, Enum: []any{"rrf", "logDice"}}
+	tests := []struct {
+		name    string
+		value   string
+		schema  *Schema
+		wantErr bool
+	}{
+		{"no schema", "anything", nil, false},
+		{"integer", "10", intSchema, false},
+		{"integer at minimum", "1", intSchema, false},
+		{"integer at maximum", "100", intSchema, false},
+		{"integer below minimum", "0", intSchema, true},
+		{"integer above maximum", "101", intSchema, true},
+		{"non-integer", "1.5", intSchema, true},
+		{"non-numeric integer", "ten", intSchema, true},
+		{"number", "0.25", numSchema, false},
+		{"negative number", "-0.25", numSchema, true},
+		{"non-numeric number", "high", numSchema, true},
+		{"enum value", "logDice", enumSchema, false},
+		{"enum value case", "logdice", enumSchema, true},
+		{"unknown enum value", "foo", enumSchema, true},
+		{"plain string", "foo", &Schema{Type: TypeString}, false},
+	}
+	for _, tt := range tests {
+		err := validateValue(tt.value, tt.schema)
+		if tt.wantErr {
+			assert.Error(t, err, tt.name)
+
+		} else {
+			assert.NoError(t, err, tt.name)
+		}
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	params := []Param{
+		PathParam("type", "collocation type", "modifiers-of", "nouns-modified-by"),
+		QueryParam("limit", "limit", &Schema{Type: TypeInteger, Minimum: json.Number("1")}),
+		{Name: "q", In: ParamInQuery, Required: true, Schema: &Schema{Type: TypeString}},
+		{Name: "X-Foo", In: "header", Required: true, Schema: &Schema{Type: TypeInteger}},
+	}
+	tests := []struct {
+		name       string
+		target     string
+		wantParam  string
+		wantCalled bool
+	}{
+		{"valid request", "/coll/modifiers-of?q=pes&limit=5", "", true},
+		{"optional parameter omitted", "/coll/modifiers-of?q=pes", "", true},
+		{"empty required query value", "/coll/modifiers-of?q=", "", true},
+		{"invalid path enum", "/coll/foo?q=pes", "type", false},
+		{"invalid integer", "/coll/modifiers-of?q=pes&limit=five", "limit", false},
+		{"integer below minimum", "/coll/modifiers-of?q=pes&limit=0", "limit", false},
+		{"missing required query", "/coll/modifiers-of?limit=5", "q", false},
+	}
+	for _, tt := range tests {
+		var called bool
+		var appErr core.AppError
+		engine := gin.New()
+		engine.GET(
+			"/coll/:type",
+			func(ctx *gin.Context) {
+				ctx.Next()
+				if len(ctx.Errors) > 0 {
+					errors.As(ctx.Errors.Last().Err, &appErr)
+				}
+			},
+			ValidateParams(params),
+			func(ctx *gin.Context) { called = true },
+		)
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+		assert.Equal(t, tt.wantCalled, called, tt.name)
+		if tt.wantCalled {
+			assert.True(t, appErr.IsZero(), tt.name)
+			continue
+		}
+		assert.Equal(t, core.ErrorTypeInvalidArguments, appErr.Type, tt.name)
+		assert.Equal(t, tt.wantParam, appErr.Param, tt.name)
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queries
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	cacheNSSimilarWords = "sw"
+	cacheNSCollocations = "coll"
+	cacheNSDictionary   = "dict"
+
+	cacheBackendDisabled = "disabled"
+)
+
+// CacheStats provides basic statistics of a result cache
+type CacheStats struct {
+	Backend     string  `json:"backend"`
+	Entries     int     `json:"entries"`
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	HitRate     float64 `json:"hitRate"`
+	Evictions   uint64  `json:"evictions"`
+	Expirations uint64  `json:"expirations"`
+}
+
+// ResultCache stores JSON-encoded query results. Implementations must
+// be safe for concurrent use. Values are stored as raw bytes so
+// the cache can be backed e.g. by a disk storage.
+type ResultCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+
+	// DeletePrefix removes all the entries with keys starting
+	// with the prefix and returns the number of removed entries
+	DeletePrefix(prefix string) int
+
+	Stats() CacheStats
+}
+
+// mkCacheKey creates a cache key from (unescaped) parts. Each part
+// is terminated by a slash so a key of any prefix of the parts can
+// be used for invalidation.
+func mkCacheKey(parts ...string) string {
+	var ans strings.Builder
+	for _, p := range parts {
+		ans.WriteString(url.PathEscape(p))
+		ans.WriteString("/")
+	}
+	return ans.String()
+}
+
+// cachedResult returns a cached value for the key or obtains it
+// via the fn function. Only successful results are cached.
+func cachedResult[T any](cache ResultCache, key string, fn func() (T, core.AppError)) (T, core.AppError) {
+	if cache == nil {
+		return fn()
+	}
+	if data, ok := cache.Get(key); ok {
+		var ans T
+		if err := json.Unmarshal(data, &ans); err == nil {
+			return ans, core.AppError{}
+
+		} else {
+			log.Warn().Err(err).Str("key", key).Msg("failed to decode cached result, ignoring")
+		}
+	}
+	ans, appErr := fn()
+	if !appErr.IsZero() {
+		return ans, appErr
+	}
+	data, err := json.Marshal(ans)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("failed to encode result for caching")
+		return ans, appErr
+	}
+	cache.Set(key, data)
+	return ans, appErr
+}
+
+// ------------------
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	created time.Time
+}
+
+// LRUCache is an in-memory ResultCache with a limited number
+// of entries (the least recently used ones are evicted first)
+// and entry time-to-live.
+type LRUCache struct {
+	capacity    int
+	ttl         time.Duration
+	items       map[string]*list.Element
+	order       *list.List
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+	mu          sync.Mutex
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elm, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	entry := elm.Value.(*lruEntry)
+	if c.ttl > 0 && time.Since(entry.created) > c.ttl {
+		c.order.Remove(elm)
+		delete(c.items, key)
+		c.expirations++
+		c.misses++
+		return nil, false
+	}
+	c.order.MoveToFront(elm)
+	c.hits++
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elm, ok := c.items[key]; ok {
+		entry := elm.Value.(*lruEntry)
+		entry.value = value
+		entry.created = time.Now()
+		c.order.MoveToFront(elm)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, created: time.Now()})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+		c.evictions++
+	}
+}
+
+func (c *LRUCache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ans int
+	for key, elm := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(elm)
+			delete(c.items, key)
+			ans++
+		}
+	}
+	return ans
+}
+
+func (c *LRUCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ans := CacheStats{
+		Backend:     "memory-lru",
+		Entries:     len(c.items),
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		ans.HitRate = float64(c.hits) / float64(total)
+	}
+	return ans
+}
+
+// NewResultCache creates a cache based on the configuration.
+// For a disabled cache, nil is returned which is a valid value
+// for SearchProvider (no caching is performed).
+func NewResultCache(conf config.ResultCacheConf) ResultCache {
+	if conf.Disabled {
+		return nil
+	}
+	var ttl time.Duration
+	if conf.TTLSecs > 0 {
+		ttl = time.Duration(conf.TTLSecs) * time.Second
+	}
+	return NewLRUCache(conf.MaxEntries, ttl)
+}
+
+// NewLRUCache is a recommended factory function for LRUCache.
+// A zero ttl means entries never expire.
+func NewLRUCache(capacity int, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		capacity: max(1, capacity),
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// ------------------
+
+// cacheCollector exposes result cache statistics as Prometheus metrics
+type cacheCollector struct {
+	searcher    *SearchProvider
+	entries     *prometheus.Desc
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	hitRate     *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.hitRate
+	ch <- c.evictions
+	ch <- c.expirations
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.searcher.CacheStats()
+	if stats.Backend == cacheBackendDisabled {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries), stats.Backend)
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits), stats.Backend)
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses), stats.Backend)
+	ch <- prometheus.MustNewConstMetric(c.hitRate, prometheus.GaugeValue, stats.HitRate, stats.Backend)
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions), stats.Backend)
+	ch <- prometheus.MustNewConstMetric(
+		c.expirations, prometheus.CounterValue, float64(stats.Expirations), stats.Backend)
+}
+
+// NewCacheCollector creates a Prometheus collector providing
+// the searcher's result cache statistics
+func NewCacheCollector(searcher *SearchProvider) prometheus.Collector {
+	mkDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "result_cache", name),
+			help,
+			[]string{"backend"},
+			nil,
+		)
+	}
+	return &cacheCollector{
+		searcher:    searcher,
+		entries:     mkDesc("entries", "Number of cached query results"),
+		hits:        mkDesc("hits_total", "Number of result cache hits"),
+		misses:      mkDesc("misses_total", "Number of result cache misses"),
+		hitRate:     mkDesc("hit_ratio", "Ratio of result cache hits to all the lookups"),
+		evictions:   mkDesc("evictions_total", "Number of results evicted due to the cache capacity"),
+		expirations: mkDesc("expirations_total", "Number of expired cached results"),
+	}
+}
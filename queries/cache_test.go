@@ -0,0 +1,263 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/model"
+	"github.com/sajari/word2vec"
+	"github.com/stretchr/testify/assert"
+)
+
+// versionedModelProvider is a W2VModelProvider with a single model
+// whose data version can be changed
+type versionedModelProvider struct {
+	*model.Provider
+	conf    model.ModelConf
+	version string
+	queries int
+}
+
+func (p *versionedModelProvider) FindModel(corpusName, modelName string) (*model.ModelConf, error) {
+	if corpusName != p.conf.Corpname || modelName != p.conf.ID {
+		return nil, model.ErrModelNotFound
+	}
+	return &p.conf, nil
+}
+
+func (p *versionedModelProvider) DataVersion(conf *model.ModelConf) (string, error) {
+	return p.version, nil
+}
+
+func (p *versionedModelProvider) Query(
+	ctx context.Context, conf *model.ModelConf, word, pos string, limit int,
+) ([]word2vec.Match, error) {
+	p.queries++
+	return []word2vec.Match{{Word: "kočka", Score: 0.8}}, nil
+}
+
+func TestMkCacheKey(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{[]string{cacheNSSimilarWords, "syn2020", "m1", "pes"}, "sw/syn2020/m1/pes/"},
+		{[]string{cacheNSDictionary, "syn2020", "a/b"}, "dict/syn2020/a%2Fb/"},
+		{[]string{cacheNSCollocations, "syn2020", ""}, "coll/syn2020//"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mkCacheKey(tt.parts...), tt.want)
+	}
+	// a key for a corpus must not be a prefix of a key for another
+	// corpus with a longer name
+	assert.NotContains(t, mkCacheKey(cacheNSDictionary, "syn2020x", "pes"), mkCacheKey(cacheNSDictionary, "syn2020"))
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := NewLRUCache(2, 0)
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	_, ok := cache.Get("a") // "b" becomes the least recently used entry
+	assert.True(t, ok)
+	cache.Set("c", []byte("3"))
+
+	tests := []struct {
+		key    string
+		want   string
+		wantOk bool
+	}{
+		{"a", "1", true},
+		{"b", "", false},
+		{"c", "3", true},
+	}
+	for _, tt := range tests {
+		v, ok := cache.Get(tt.key)
+		assert.Equal(t, tt.wantOk, ok, tt.key)
+		if tt.wantOk {
+			assert.Equal(t, tt.want, string(v), tt.key)
+		}
+	}
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.InDelta(t, 0.75, stats.HitRate, 1e-9)
+}
+
+func TestLRUCacheUpdate(t *testing.T) {
+	cache := NewLRUCache(2, 0)
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Set("a", []byte("3")) // updating makes "a" the most recently used entry
+	cache.Set("c", []byte("4"))
+	v, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "3", string(v))
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Stats().Entries)
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	cache := NewLRUCache(10, 50*time.Millisecond)
+	cache.Set("a", []byte("1"))
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	time.Sleep(100 * time.Millisecond)
+	cache.Set("b", []byte("2"))
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	_, ok = cache.Get("b")
+	assert.True(t, ok)
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Expirations)
+}
+
+func TestLRUCacheDeletePrefix(t *testing.T) {
+	keys := []string{
+		mkCacheKey(cacheNSSimilarWords, "syn2020", "m1", "pes"),
+		mkCacheKey(cacheNSSimilarWords, "syn2020", "m2", "pes"),
+		mkCacheKey(cacheNSSimilarWords, "syn2020x", "m1", "pes"),
+		mkCacheKey(cacheNSCollocations, "syn2020", "", "pes"),
+		mkCacheKey(cacheNSDictionary, "syn2020", "", "pes"),
+	}
+	tests := []struct {
+		name      string
+		prefix    string
+		wantCount int
+		wantKept  []string
+	}{
+		{
+			name:      "single model",
+			prefix:    mkCacheKey(cacheNSSimilarWords, "syn2020", "m1"),
+			wantCount: 1,
+			wantKept:  []string{keys[1], keys[2], keys[3], keys[4]},
+		},
+		{
+			name:      "dataset does not match a longer dataset name",
+			prefix:    mkCacheKey(cacheNSSimilarWords, "syn2020"),
+			wantCount: 2,
+			wantKept:  []string{keys[2], keys[3], keys[4]},
+		},
+		{
+			name:      "namespace",
+			prefix:    mkCacheKey(cacheNSCollocations),
+			wantCount: 1,
+			wantKept:  []string{keys[0], keys[1], keys[2], keys[4]},
+		},
+		{
+			name:      "no match",
+			prefix:    mkCacheKey(cacheNSDictionary, "syn2015"),
+			wantCount: 0,
+			wantKept:  keys,
+		},
+		{
+			name:      "everything",
+			prefix:    "",
+			wantCount: len(keys),
+		},
+	}
+	for _, tt := range tests {
+		cache := NewLRUCache(len(keys)+1, 0)
+		for _, k := range keys {
+			cache.Set(k, []byte("{}"))
+		}
+		assert.Equal(t, tt.wantCount, cache.DeletePrefix(tt.prefix), tt.name)
+		assert.Equal(t, len(tt.wantKept), cache.Stats().Entries, tt.name)
+		for _, k := range tt.wantKept {
+			_, ok := cache.Get(k)
+			assert.True(t, ok, tt.name)
+		}
+		// the LRU list must stay consistent with the index
+		cache.Set("new", []byte("{}"))
+		assert.Equal(t, len(tt.wantKept)+1, cache.order.Len(), tt.name)
+	}
+}
+
+func TestCachedResult(t *testing.T) {
+	cache := NewLRUCache(10, 0)
+	var calls int
+	fn := func() ([]string, core.AppError) {
+		calls++
+		return []string{"pes", "kočka"}, core.AppError{}
+	}
+	for i := 0; i < 2; i++ {
+		ans, appErr := cachedResult(cache, "k", fn)
+		assert.True(t, appErr.IsZero())
+		assert.Equal(t, []string{"pes", "kočka"}, ans)
+	}
+	assert.Equal(t, 1, calls)
+
+	failing := func() ([]string, core.AppError) {
+		calls++
+		return nil, core.NewAppError("failed", core.ErrorTypeInternalError, nil)
+	}
+	for i := 0; i < 2; i++ {
+		_, appErr := cachedResult(cache, "f", failing)
+		assert.False(t, appErr.IsZero())
+	}
+	assert.Equal(t, 3, calls)
+
+	ans, appErr := cachedResult(nil, "k", fn)
+	assert.True(t, appErr.IsZero())
+	assert.Equal(t, []string{"pes", "kočka"}, ans)
+	assert.Equal(t, 4, calls)
+}
+
+func TestSimilarWordsCacheFollowsModelVersion(t *testing.T) {
+	paths := model.NewPathResolver(t.TempDir(), nil)
+	models := &versionedModelProvider{
+		Provider: model.NewProvider(paths, nil, 0),
+		conf:     model.ModelConf{Corpname: "syn2020", ID: "m1", ContainsPoS: true},
+		version:  "v1",
+	}
+	collDBs, err := NewCollDbMap(paths, nil)
+	assert.NoError(t, err)
+	searcher, err := NewSearchProvider("", collDBs, models, nil, NewLRUCache(10, 0))
+	assert.NoError(t, err)
+	query := func() {
+		_, appErr := searcher.SimilarlyUsedWords(context.Background(), "syn2020", "m1", "N", "pes", 10, 0)
+		assert.True(t, appErr.IsZero())
+	}
+	tests := []struct {
+		name        string
+		version     string
+		wantQueries int
+		wantHits    uint64
+		wantMisses  uint64
+	}{
+		{"first query", "v1", 1, 0, 1},
+		{"same version", "v1", 1, 1, 1},
+		{"changed version", "v2", 2, 1, 2},
+		{"changed version cached", "v2", 2, 2, 2},
+	}
+	for _, tt := range tests {
+		models.version = tt.version
+		query()
+		stats := searcher.CacheStats()
+		assert.Equal(t, tt.wantQueries, models.queries, tt.name)
+		assert.Equal(t, tt.wantHits, stats.Hits, tt.name)
+		assert.Equal(t, tt.wantMisses, stats.Misses, tt.name)
+	}
+}
@@ -1,25 +1,43 @@
 package queries
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/czcorpus/cnc-gokit/collections"
 	"github.com/czcorpus/depreldb/storage"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/metrics"
 	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/tracing"
+	"github.com/invopop/jsonschema"
+	"github.com/rs/zerolog/log"
 	"github.com/sajari/word2vec"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	posIDs = []string{"N", "A", "P", "C", "V", "D", "R", "J", "T", "I", "Z", "X"}
 )
 
+var tracer = otel.Tracer("github.com/czcorpus/wsserver/queries")
+
 type W2VModelProvider interface {
 	FindModel(corpusName string, modelName string) (*model.ModelConf, error)
-	Query(conf *model.ModelConf, word, pos string, limit int) ([]word2vec.Match, error)
-	ListModels(corpname string) ([]model.ModelInfo, error)
+	Query(ctx context.Context, conf *model.ModelConf, word, pos string, limit int) ([]word2vec.Match, error)
+	ListModels(corpname string, accept func(conf *model.ModelConf) bool) ([]model.ModelInfo, error)
+	ListModelStatus(corpname string) []model.ModelStatus
+	Corpora() []string
+	DataVersion(conf *model.ModelConf) (string, error)
+	AcquireScanSlot(ctx context.Context) (func(), error)
+	Close(ctx context.Context) error
 }
 
 // ResultRow represents a single result item for "similar words"
@@ -31,27 +49,139 @@ type ResultRow struct {
 
 // --------------------------------
 
-type CollDBMap map[string]*storage.DB
+// CollDB is an opened collocation database along with its configuration
+type CollDB struct {
+	Conf model.CollDBConf
+	DB   *storage.DB
+
+	// Version identifies the database data at the time
+	// of opening (see model.DataVersion). Empty if unknown.
+	Version string
+}
+
+// CollDBMap holds all the opened collocation databases
+// keyed by their IDs
+type CollDBMap struct {
+	dbs      map[string]*CollDB
+	order    []string
+	defaults map[string]string
+}
 
-func (dbmap CollDBMap) Contains(key string) bool {
-	_, ok := dbmap[key]
+// Contains tests whether a database with the specified ID exists
+func (dbmap *CollDBMap) Contains(dbID string) bool {
+	_, ok := dbmap.dbs[dbID]
 	return ok
 }
 
-func NewCollDbMap(modelConfigs []model.ModelConf) (CollDBMap, error) {
-	collDbs := make(CollDBMap)
-	for _, conf := range modelConfigs {
-		if conf.SyntaxDatabasePath != "" {
-			db, err := storage.OpenDB(conf.SyntaxDatabasePath)
-			if err != nil {
-				return collDbs, fmt.Errorf("failed to instantiate coll database for %s: %w", conf.Corpname, err)
-			}
-			collDbs[conf.Corpname] = db
+// HasCorpus tests whether there is at least one database for the corpus
+func (dbmap *CollDBMap) HasCorpus(corpusID string) bool {
+	_, ok := dbmap.defaults[corpusID]
+	return ok
+}
+
+// ForCorpus returns all the databases of a corpus in the order
+// of configuration
+func (dbmap *CollDBMap) ForCorpus(corpusID string) []*CollDB {
+	ans := make([]*CollDB, 0, 3)
+	for _, dbID := range dbmap.order {
+		if db := dbmap.dbs[dbID]; db.Conf.Corpname == corpusID {
+			ans = append(ans, db)
+		}
+	}
+	return ans
+}
+
+// IsDefault tells whether the database is the one used
+// for its corpus in case no database is specified explicitly
+func (dbmap *CollDBMap) IsDefault(db *CollDB) bool {
+	return dbmap.defaults[db.Conf.Corpname] == db.Conf.ID
+}
+
+// Find returns a database with the specified ID belonging to the
+// specified corpus. If dbID is empty, the corpus default database
+// is returned.
+func (dbmap *CollDBMap) Find(corpusID, dbID string) (*CollDB, bool) {
+	if dbID == "" {
+		dbID = dbmap.defaults[corpusID]
+	}
+	db, ok := dbmap.dbs[dbID]
+	if !ok || db.Conf.Corpname != corpusID {
+		return nil, false
+	}
+	return db, true
+}
+
+// Close closes all the databases. It should be called once
+// no queries are running. Errors of individual databases are
+// logged and returned joined.
+func (dbmap *CollDBMap) Close() error {
+	errs := make([]error, 0, len(dbmap.order))
+	for _, dbID := range dbmap.order {
+		if err := dbmap.dbs[dbID].DB.Close(); err != nil {
+			log.Error().Err(err).Str("collDb", dbID).Msg("failed to close coll database")
+			errs = append(errs, fmt.Errorf("failed to close coll database %s: %w", dbID, err))
+			continue
+		}
+		log.Info().Str("collDb", dbID).Msg("coll database closed")
+	}
+	return errors.Join(errs...)
+}
+
+// NewCollDbMap opens all the configured collocation databases.
+// In case of an error, already opened databases are closed.
+func NewCollDbMap(paths *model.PathResolver, dbConfigs []model.CollDBConf) (*CollDBMap, error) {
+	collDbs := &CollDBMap{
+		dbs:      make(map[string]*CollDB),
+		order:    make([]string, 0, len(dbConfigs)),
+		defaults: make(map[string]string),
+	}
+	for _, conf := range dbConfigs {
+		if collDbs.Contains(conf.ID) {
+			collDbs.Close()
+			return nil, fmt.Errorf("duplicate coll database %s", conf.ID)
+		}
+		dbPath, err := paths.CollDBPath(&conf)
+		if err != nil {
+			collDbs.Close()
+			return nil, fmt.Errorf("failed to resolve coll database path for %s: %w", conf.ID, err)
+		}
+		db, err := storage.OpenDB(dbPath)
+		if err != nil {
+			collDbs.Close()
+			return nil, fmt.Errorf("failed to instantiate coll database %s: %w", conf.ID, err)
+		}
+		version, err := model.DataVersion(dbPath)
+		if err != nil {
+			log.Warn().Err(err).Str("collDb", conf.ID).Msg("failed to determine coll database version")
+		}
+		collDbs.dbs[conf.ID] = &CollDB{Conf: conf, DB: db, Version: version}
+		collDbs.order = append(collDbs.order, conf.ID)
+		if _, ok := collDbs.defaults[conf.Corpname]; !ok || conf.Default {
+			collDbs.defaults[conf.Corpname] = conf.ID
 		}
 	}
 	return collDbs, nil
 }
 
+// collDBOperation starts tracing and measuring of a collocation
+// database operation. The returned function must be called once
+// the operation is finished.
+func collDBOperation(ctx context.Context, operation string, collDB *CollDB) (context.Context, func(appErr core.AppError)) {
+	t0 := time.Now()
+	ctx, span := tracer.Start(
+		ctx,
+		"colldb."+operation,
+		trace.WithAttributes(
+			attribute.String("corpus", collDB.Conf.Corpname),
+			attribute.String("coll_db", collDB.Conf.ID),
+		),
+	)
+	return ctx, func(appErr core.AppError) {
+		metrics.ObserveCollDBQuery(operation, collDB.Conf.Corpname, collDB.Conf.ID, t0)
+		tracing.EndSpan(span, appErr)
+	}
+}
+
 func splitByLastUnderscore(s string) (string, string) {
 	lastIndex := strings.LastIndex(s, "_")
 	if lastIndex == -1 {
@@ -100,6 +230,14 @@ func (v SafeFloat) MarshalJSON() ([]byte, error) {
 	return json.Marshal(float64(v))
 }
 
+// JSONSchema describes the value for JSON schema generators
+// (NaN and infinite values are encoded as null)
+func (v SafeFloat) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{{Type: "number"}, {Type: "null"}},
+	}
+}
+
 type SimpleCollocation struct {
 	SearchMatch LemmaInfo `json:"searchMatch"`
 	Collocate   LemmaInfo `json:"collocate"`
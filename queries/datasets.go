@@ -0,0 +1,171 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queries
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/czcorpus/wsserver/auth"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/model"
+)
+
+type Feature string
+
+const (
+	FeatureSimilarWords       Feature = "similarWords"
+	FeatureCollocations       Feature = "collocations"
+	FeatureCollocationsOfType Feature = "collocationsOfType"
+	FeatureDictionary         Feature = "dictionary"
+)
+
+// CollDBInfo describes a collocation database of a dataset
+type CollDBInfo struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Default     bool   `json:"default"`
+}
+
+// DatasetInfo describes everything the server provides
+// for a single dataset (corpus)
+type DatasetInfo struct {
+	ID            string              `json:"id"`
+	Description   string              `json:"description,omitempty"`
+	Models        []model.ModelStatus `json:"models"`
+	CollDatabases []CollDBInfo        `json:"collDatabases"`
+	Features      []Feature           `json:"features"`
+}
+
+// HasFeature tells whether the dataset supports the feature
+func (info DatasetInfo) HasFeature(f Feature) bool {
+	return slices.Contains(info.Features, f)
+}
+
+// isKnownDataset tells whether the dataset is described in corpora
+// configuration or referenced by a model or a collocation database
+func (wss *SearchProvider) isKnownDataset(datasetID string) bool {
+	if _, ok := wss.corpora[datasetID]; ok {
+		return true
+	}
+	return slices.Contains(wss.modelProvider.Corpora(), datasetID) || wss.collDBs.HasCorpus(datasetID)
+}
+
+// collDBNotFound creates a proper error for a failed collocation
+// database lookup. Known datasets without any collocation database
+// (i.e. without the collocation and dictionary features) produce
+// ErrorTypeUnsupportedFeature even if a database ID is specified.
+func (wss *SearchProvider) collDBNotFound(datasetID, collDBID string) core.AppError {
+	switch {
+	case !wss.isKnownDataset(datasetID):
+		return core.NewAppError(
+			fmt.Sprintf("unknown dataset %s", datasetID), core.ErrorTypeNotFound, nil).WithParam("corpusId")
+	case collDBID != "" && wss.collDBs.HasCorpus(datasetID):
+		return core.NewAppError(
+			fmt.Sprintf("collocations database %s/%s not found", datasetID, collDBID),
+			core.ErrorTypeNotFound,
+			nil,
+		).WithParam("collDb")
+	default:
+		return core.NewAppError(
+			fmt.Sprintf("dataset %s does not provide collocations", datasetID),
+			core.ErrorTypeUnsupportedFeature,
+			nil,
+		)
+	}
+}
+
+// Datasets lists all the datasets known from corpora, models
+// or collocation databases configuration.
+// The method does not load any model.
+func (wss *SearchProvider) Datasets(ctx context.Context) ([]DatasetInfo, core.AppError) {
+	_, span := tracer.Start(ctx, "SearchProvider.Datasets")
+	defer span.End()
+	ids := wss.modelProvider.Corpora()
+	for _, db := range wss.collDBs.dbs {
+		if !slices.Contains(ids, db.Conf.Corpname) {
+			ids = append(ids, db.Conf.Corpname)
+		}
+	}
+	for corpusID := range wss.corpora {
+		if !slices.Contains(ids, corpusID) {
+			ids = append(ids, corpusID)
+		}
+	}
+	principal := auth.FromContext(ctx)
+	ids = slices.DeleteFunc(ids, func(id string) bool { return !principal.CanAccessDataset(id) })
+	slices.Sort(ids)
+	ans := make([]DatasetInfo, len(ids))
+	for i, corpusID := range ids {
+		ans[i] = wss.datasetInfo(principal, corpusID)
+	}
+	return ans, core.AppError{}
+}
+
+// ListModels provides detailed information about models of a dataset
+// accessible by the client. Please note that the method loads all
+// the listed models (models the client cannot access are not loaded).
+func (wss *SearchProvider) ListModels(ctx context.Context, datasetID string) ([]model.ModelInfo, core.AppError) {
+	if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+		return []model.ModelInfo{}, appErr
+	}
+	principal := auth.FromContext(ctx)
+	ans, err := wss.modelProvider.ListModels(
+		datasetID,
+		func(conf *model.ModelConf) bool { return principal.CanAccessModel(datasetID, conf.ID) },
+	)
+	if err != nil {
+		return []model.ModelInfo{}, core.NewAppError("failed to list models", core.ErrorTypeInternalError, err)
+	}
+	return ans, core.AppError{}
+}
+
+// datasetInfo describes the dataset with only the models
+// accessible by the principal
+func (wss *SearchProvider) datasetInfo(principal *auth.Principal, corpusID string) DatasetInfo {
+	models := slices.DeleteFunc(
+		wss.modelProvider.ListModelStatus(corpusID),
+		func(m model.ModelStatus) bool { return !principal.CanAccessModel(corpusID, m.ID) },
+	)
+	ans := DatasetInfo{
+		ID:            corpusID,
+		Description:   wss.corpora[corpusID].Description,
+		Models:        models,
+		CollDatabases: make([]CollDBInfo, 0, 3),
+		Features:      make([]Feature, 0, 4),
+	}
+	for _, db := range wss.collDBs.ForCorpus(corpusID) {
+		ans.CollDatabases = append(ans.CollDatabases, CollDBInfo{
+			ID:          db.Conf.ID,
+			Description: db.Conf.Description,
+			Default:     wss.collDBs.IsDefault(db),
+		})
+	}
+	if len(ans.Models) > 0 {
+		ans.Features = append(ans.Features, FeatureSimilarWords)
+	}
+	if len(ans.CollDatabases) > 0 {
+		ans.Features = append(
+			ans.Features,
+			FeatureCollocations,
+			FeatureCollocationsOfType,
+			FeatureDictionary,
+		)
+	}
+	return ans
+}
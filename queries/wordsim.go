@@ -2,15 +2,25 @@ package queries
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/czcorpus/depreldb/scoll"
+	"github.com/czcorpus/depreldb/storage"
+	"github.com/czcorpus/wsserver/auth"
 	"github.com/czcorpus/wsserver/core"
+	"github.com/czcorpus/wsserver/corpora"
 	"github.com/czcorpus/wsserver/model"
+	"github.com/czcorpus/wsserver/tracing"
+	"github.com/rs/zerolog/log"
 	"github.com/sajari/word2vec"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func isNotFound(err error) bool {
@@ -38,79 +48,201 @@ func exportResult(matches []word2vec.Match, minScore float32) []ResultRow {
 	return ans
 }
 
+// modelError converts model provider errors to application errors
+func modelError(err error) core.AppError {
+	switch {
+	case errors.Is(err, model.ErrModelConfNotFound), errors.Is(err, model.ErrModelNotFound):
+		return core.NewAppError("failed to get requested model", core.ErrorTypeNotFound, err)
+	case errors.Is(err, model.ErrModelLoading):
+		return core.NewAppError("the model is being loaded, please try again later", core.ErrorTypeModelLoading, nil)
+	case errors.Is(err, model.ErrProviderClosed):
+		return core.NewAppError("the service is shutting down", core.ErrorTypeUnavailable, nil)
+	default:
+		return core.NewAppError("failed to get requested model", core.ErrorTypeInternalError, err)
+	}
+}
+
+// contextError converts an error of a cancelled context
+// to an application error. For other errors, a zero
+// AppError is returned.
+func contextError(err error) core.AppError {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return core.NewAppError("query deadline exceeded", core.ErrorTypeTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return core.NewAppError("query cancelled", core.ErrorTypeCancelled, err)
+	default:
+		return core.AppError{}
+	}
+}
+
+// runWithContext runs a function which does not support cancellation
+// and returns early once the context is cancelled. The function
+// keeps running in background in such case, still holding its scan
+// slot (acquireSlot) so abandoned functions cannot pile up.
+func runWithContext[T any](
+	ctx context.Context,
+	acquireSlot func(ctx context.Context) (func(), error),
+	fn func() (T, error),
+) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	release, err := acquireSlot(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	ch := make(chan result, 1)
+	go func() {
+		defer release()
+		v, err := fn()
+		ch <- result{v, err}
+	}()
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case res := <-ch:
+		return res.value, res.err
+	}
+}
+
 // ---------
 
 type SearchProvider struct {
-	collDBs       CollDBMap
+	collDBs       *CollDBMap
 	modelProvider W2VModelProvider
+	corpora       map[string]corpora.Info
+	cache         ResultCache
 }
 
+// Close releases all the models (pending loads are cancelled) and
+// closes the collocation databases. It should be called once no
+// queries are running. The context limits waiting for model loads.
+func (wss *SearchProvider) Close(ctx context.Context) error {
+	errs := make([]error, 0, 2)
+	if err := wss.modelProvider.Close(ctx); err != nil {
+		errs = append(errs, err)
+
+	} else {
+		log.Info().Msg("models released")
+	}
+	if err := wss.collDBs.Close(); err != nil {
+		errs = append(errs, err)
+
+	} else {
+		log.Info().Msg("all coll databases closed")
+	}
+	return errors.Join(errs...)
+}
+
+// CacheStats returns statistics of the result cache
+func (wss *SearchProvider) CacheStats() CacheStats {
+	if wss.cache == nil {
+		return CacheStats{Backend: cacheBackendDisabled}
+	}
+	return wss.cache.Stats()
+}
+
+// ModelVersion returns a version of the model's data.
+// For an unknown model, an empty string is returned.
+func (wss *SearchProvider) ModelVersion(datasetID, modelID string) string {
+	conf, err := wss.modelProvider.FindModel(datasetID, modelID)
+	if err != nil {
+		return ""
+	}
+	version, err := wss.modelProvider.DataVersion(conf)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// CollDBVersion returns a version of the collocation database's data.
+// If collDBID is empty, the dataset's default database is used.
+// For an unknown database, an empty string is returned.
+func (wss *SearchProvider) CollDBVersion(datasetID, collDBID string) string {
+	collDB, ok := wss.collDBs.Find(datasetID, collDBID)
+	if !ok {
+		return ""
+	}
+	return collDB.Version
+}
+
+// SimilarlyUsedWords searches for words used in similar contexts
+// as the provided word. Results are cached (the key contains
+// the model's data version so a changed model is never answered
+// from the cache).
 func (wss *SearchProvider) SimilarlyUsedWords(
 	ctx context.Context,
 	datasetID, modelID, posOrSfn, word string,
 	limit int,
 	minScore float32,
+) (ans []ResultRow, appErr core.AppError) {
+	ctx, span := tracer.Start(
+		ctx,
+		"SearchProvider.SimilarlyUsedWords",
+		trace.WithAttributes(
+			attribute.String("dataset", datasetID),
+			attribute.String("model", modelID),
+			attribute.String("word", word),
+			attribute.String("fn", posOrSfn),
+			attribute.Int("limit", limit),
+		),
+	)
+	defer func() { tracing.EndSpan(span, appErr) }()
+	if appErr := auth.CheckAccess(ctx, datasetID, modelID); !appErr.IsZero() {
+		return []ResultRow{}, appErr
+	}
+	key := mkCacheKey(
+		cacheNSSimilarWords,
+		datasetID,
+		modelID,
+		wss.ModelVersion(datasetID, modelID),
+		posOrSfn,
+		word,
+		strconv.Itoa(limit),
+		strconv.FormatFloat(float64(minScore), 'g', -1, 32),
+	)
+	return cachedResult(wss.cache, key, func() ([]ResultRow, core.AppError) {
+		return wss.similarlyUsedWords(ctx, datasetID, modelID, posOrSfn, word, limit, minScore)
+	})
+}
+
+func (wss *SearchProvider) similarlyUsedWords(
+	ctx context.Context,
+	datasetID, modelID, posOrSfn, word string,
+	limit int,
+	minScore float32,
 ) ([]ResultRow, core.AppError) {
 
 	var syntaxFnMatches []string
 
 	modelConf, err := wss.modelProvider.FindModel(datasetID, modelID)
-	if err == model.ErrModelConfNotFound || err == model.ErrModelNotFound {
-		return []ResultRow{}, core.NewAppError(
-			"failed to get requested model",
-			core.ErrorTypeNotFound,
-			err,
-		)
-	}
 	if err != nil {
-		return []ResultRow{}, core.NewAppError(
-			"failed to get requested model",
-			core.ErrorTypeInternalError,
-			err,
-		)
+		return []ResultRow{}, modelError(err).WithParam("modelId")
 	}
 
 	if !modelConf.ContainsPoS {
 		if posOrSfn != "" {
 			return []ResultRow{}, core.NewAppError(
-				"The model does not support setting PoS",
-				core.ErrorTypeInvalidArguments,
+				"the model does not support setting PoS",
+				core.ErrorTypeUnsupportedFeature,
 				nil,
-			)
+			).WithParam("fn")
 		}
 		syntaxFnMatches = []string{""}
 
 	} else if posOrSfn != "" {
 		syntaxFnMatches = []string{posOrSfn}
 
-	} else if wss.collDBs.Contains(datasetID) {
-		db := wss.collDBs[datasetID]
-		variants, err := db.GetLemmaIDsByPrefix(word)
-		if err != nil {
-			return []ResultRow{}, core.NewAppError(
-				"failed to get matching variants",
-				core.ErrorTypeInternalError,
-				err,
-			)
-		}
-		for _, v := range variants {
-			if v.Value != word {
-				continue
-			}
-			entries, err := db.GetLemmaDeprelValues(v.TokenID)
-			if err != nil {
-				return []ResultRow{}, core.NewAppError(
-					"failed to get requested model",
-					core.ErrorTypeInternalError,
-					err,
-				)
-			}
-			if len(entries) > 10 {
-				entries = entries[:10]
-			}
-			for _, entry := range entries {
-				syntaxFnMatches = append(syntaxFnMatches, entry.Value)
-			}
+	} else if collDB, ok := wss.collDBs.Find(datasetID, modelConf.CollDBID()); ok {
+		var appErr core.AppError
+		syntaxFnMatches, appErr = wss.syntaxFunctions(ctx, collDB, word)
+		if !appErr.IsZero() {
+			return []ResultRow{}, appErr
 		}
 
 	} else {
@@ -119,9 +251,15 @@ func (wss *SearchProvider) SimilarlyUsedWords(
 
 	ans := make([]ResultRow, 0, len(syntaxFnMatches)*limit)
 	for _, posItem := range syntaxFnMatches {
-		fmt.Println("POSITEM >> ", posItem)
-		matches, err := wss.modelProvider.Query(modelConf, word, posItem, limit+1)
-		if err != nil && !isNotFound(err) {
+		matches, err := wss.modelProvider.Query(ctx, modelConf, word, posItem, limit+1)
+		if appErr := contextError(err); !appErr.IsZero() {
+			return []ResultRow{}, appErr
+
+		} else if errors.Is(err, model.ErrModelNotFound) || errors.Is(err, model.ErrModelLoading) ||
+			errors.Is(err, model.ErrProviderClosed) {
+			return []ResultRow{}, modelError(err)
+
+		} else if err != nil && !isNotFound(err) {
 			return []ResultRow{}, core.NewAppError(
 				"problem evaluation word similarity query",
 				core.ErrorTypeInternalError,
@@ -140,34 +278,71 @@ func (wss *SearchProvider) SimilarlyUsedWords(
 	return ans, core.AppError{}
 }
 
+// Collocations searches for collocations of a word. The collDBID
+// argument selects one of the dataset's collocation databases.
+// If empty, the dataset's default database is used. Results are cached.
 func (wss *SearchProvider) Collocations(
 	ctx context.Context,
-	datasetID, word string,
+	datasetID, collDBID, word string,
 	options ...func(opts *scoll.CalculationOptions),
-) ([]SimpleCollocation, core.AppError) {
-
-	if !wss.collDBs.Contains(datasetID) {
+) (ans []SimpleCollocation, appErr core.AppError) {
+	ctx, span := tracer.Start(
+		ctx,
+		"SearchProvider.Collocations",
+		trace.WithAttributes(
+			attribute.String("dataset", datasetID),
+			attribute.String("coll_db", collDBID),
+			attribute.String("word", word),
+		),
+	)
+	defer func() { tracing.EndSpan(span, appErr) }()
+	if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+		return []SimpleCollocation{}, appErr
+	}
+	collDB, ok := wss.collDBs.Find(datasetID, collDBID)
+	if !ok {
+		return []SimpleCollocation{}, wss.collDBNotFound(datasetID, collDBID)
+	}
+	var opts scoll.CalculationOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	encOpts, err := json.Marshal(opts)
+	if err != nil {
 		return []SimpleCollocation{}, core.NewAppError(
-			fmt.Sprintf("collocations dataset %s not found", datasetID),
-			core.ErrorTypeNotFound,
-			nil,
-		)
+			"failed to process calculation options", core.ErrorTypeInternalError, err)
 	}
-	db := wss.collDBs[datasetID]
+	span.SetAttributes(attribute.String("options", string(encOpts)))
+	key := mkCacheKey(cacheNSCollocations, datasetID, collDB.Conf.ID, collDB.Version, word, string(encOpts))
+	return cachedResult(wss.cache, key, func() ([]SimpleCollocation, core.AppError) {
+		return wss.collocations(ctx, datasetID, collDB, word, options...)
+	})
+}
 
-	result, err := scoll.FromDatabase(db).GetCollocations(
-		word,
-		options...,
-	)
-	if err != nil {
+func (wss *SearchProvider) collocations(
+	ctx context.Context,
+	datasetID string,
+	collDB *CollDB,
+	word string,
+	options ...func(opts *scoll.CalculationOptions),
+) (ans []SimpleCollocation, appErr core.AppError) {
+	ctx, finish := collDBOperation(ctx, "collocations", collDB)
+	defer func() { finish(appErr) }()
+	result, err := runWithContext(ctx, wss.modelProvider.AcquireScanSlot, func() ([]storage.Collocation, error) {
+		return scoll.FromDatabase(collDB.DB).GetCollocations(word, options...)
+	})
+	if appErr := contextError(err); !appErr.IsZero() {
+		return []SimpleCollocation{}, appErr
+
+	} else if err != nil {
 		return []SimpleCollocation{}, core.NewAppError(
-			fmt.Sprintf("collocations dataset %s not found", datasetID),
+			fmt.Sprintf("failed to get collocations from dataset %s", datasetID),
 			core.ErrorTypeInternalError,
 			err,
 		)
 	}
 
-	ans := make([]SimpleCollocation, len(result))
+	ans = make([]SimpleCollocation, len(result))
 	for i, v := range result {
 		ans[i] = SimpleCollocation{
 			SearchMatch: LemmaInfo{
@@ -191,44 +366,75 @@ func (wss *SearchProvider) Collocations(
 	return ans, core.AppError{}
 }
 
-type dictItem struct {
+// DictItem represents a single lemma variant (PoS, text type)
+// along with its frequency
+type DictItem struct {
 	Lemma    string    `json:"lemma"`
 	PoS      string    `json:"pos"`
 	Freq     SafeFloat `json:"freq"`
 	TextType string    `json:"textType"`
 }
 
-func (wss *SearchProvider) Dictionary(datasetID, word string) ([]dictItem, core.AppError) {
-	fmt.Println("DATASET: ", datasetID)
-	db, ok := wss.collDBs[datasetID]
+// Dictionary provides basic lemma properties (PoS, frequency per text type).
+// The collDBID argument selects one of the dataset's collocation databases.
+// If empty, the dataset's default database is used. Results are cached.
+func (wss *SearchProvider) Dictionary(
+	ctx context.Context,
+	datasetID, collDBID, word string,
+) (ans []DictItem, appErr core.AppError) {
+	ctx, span := tracer.Start(
+		ctx,
+		"SearchProvider.Dictionary",
+		trace.WithAttributes(
+			attribute.String("dataset", datasetID),
+			attribute.String("coll_db", collDBID),
+			attribute.String("word", word),
+		),
+	)
+	defer func() { tracing.EndSpan(span, appErr) }()
+	if appErr := auth.CheckAccess(ctx, datasetID, ""); !appErr.IsZero() {
+		return []DictItem{}, appErr
+	}
+	collDB, ok := wss.collDBs.Find(datasetID, collDBID)
 	if !ok {
-		return []dictItem{}, core.NewAppError(
-			fmt.Sprintf("unknown dataset: %s", datasetID), core.ErrorTypeNotFound, nil)
+		return []DictItem{}, wss.collDBNotFound(datasetID, collDBID)
 	}
+	key := mkCacheKey(cacheNSDictionary, datasetID, collDB.Conf.ID, collDB.Version, word)
+	return cachedResult(wss.cache, key, func() ([]DictItem, core.AppError) {
+		return wss.dictionary(ctx, collDB, word)
+	})
+}
+
+func (wss *SearchProvider) dictionary(ctx context.Context, collDB *CollDB, word string) (ans []DictItem, appErr core.AppError) {
+	ctx, finish := collDBOperation(ctx, "dictionary", collDB)
+	defer func() { finish(appErr) }()
+	db := collDB.DB
 	variants, err := db.GetLemmaIDsByPrefix(word)
-	fmt.Println("VARIANRTS: ", variants)
 	if err != nil {
-		return []dictItem{}, core.NewAppError(
+		return []DictItem{}, core.NewAppError(
 			"failed to get matching lemmas",
 			core.ErrorTypeInternalError,
 			err,
 		)
 	}
-	ans := make([]dictItem, 0, 20)
+	ans = make([]DictItem, 0, 20)
 	for _, v := range variants {
 		if v.Value != word {
 			continue
 		}
+		if appErr := contextError(ctx.Err()); !appErr.IsZero() {
+			return []DictItem{}, appErr
+		}
 		entries, err := db.GetMatchingLemmaProps(v.TokenID)
 		if err != nil {
-			return []dictItem{}, core.NewAppError(
+			return []DictItem{}, core.NewAppError(
 				"failed to get requested model",
 				core.ErrorTypeInternalError,
 				err,
 			)
 		}
 		for _, entry := range entries {
-			ans = append(ans, dictItem{
+			ans = append(ans, DictItem{
 				Lemma:    v.Value,
 				PoS:      entry.Pos,
 				Freq:     SafeFloat(entry.Freq),
@@ -239,14 +445,62 @@ func (wss *SearchProvider) Dictionary(datasetID, word string) ([]dictItem, core.
 	return ans, core.AppError{}
 }
 
+// syntaxFunctions finds the most frequent syntactic functions
+// of the word's variants
+func (wss *SearchProvider) syntaxFunctions(
+	ctx context.Context,
+	collDB *CollDB,
+	word string,
+) (ans []string, appErr core.AppError) {
+	ctx, finish := collDBOperation(ctx, "syntaxFunctions", collDB)
+	defer func() { finish(appErr) }()
+	db := collDB.DB
+	variants, err := db.GetLemmaIDsByPrefix(word)
+	if err != nil {
+		return []string{}, core.NewAppError(
+			"failed to get matching variants",
+			core.ErrorTypeInternalError,
+			err,
+		)
+	}
+	for _, v := range variants {
+		if v.Value != word {
+			continue
+		}
+		if appErr := contextError(ctx.Err()); !appErr.IsZero() {
+			return []string{}, appErr
+		}
+		entries, err := db.GetLemmaDeprelValues(v.TokenID)
+		if err != nil {
+			return []string{}, core.NewAppError(
+				"failed to get requested model",
+				core.ErrorTypeInternalError,
+				err,
+			)
+		}
+		if len(entries) > 10 {
+			entries = entries[:10]
+		}
+		for _, entry := range entries {
+			ans = append(ans, entry.Value)
+		}
+	}
+	return ans, core.AppError{}
+}
+
 func NewSearchProvider(
 	dataDir string,
-	collDbs CollDBMap,
+	collDbs *CollDBMap,
 	w2vModels W2VModelProvider,
+	corpInfo map[string]corpora.Info,
+	cache ResultCache,
 ) (*SearchProvider, error) {
 
-	return &SearchProvider{
+	ans := &SearchProvider{
 		collDBs:       collDbs,
 		modelProvider: w2vModels,
-	}, nil
+		corpora:       corpInfo,
+		cache:         cache,
+	}
+	return ans, nil
 }
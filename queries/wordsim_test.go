@@ -0,0 +1,49 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/czcorpus/wsserver/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWithContextHoldsSlotOfAbandonedFunction(t *testing.T) {
+	provider := model.NewProvider(model.NewPathResolver(t.TempDir(), nil), nil, 1)
+	unblock := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := runWithContext(ctx, provider.AcquireScanSlot, func() (int, error) {
+		<-unblock
+		return 1, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the abandoned function still occupies the only slot
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel2()
+	_, err = runWithContext(ctx2, provider.AcquireScanSlot, func() (int, error) { return 2, nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(unblock)
+	v, err := runWithContext(context.Background(), provider.AcquireScanSlot, func() (int, error) { return 3, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+}
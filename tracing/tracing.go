@@ -0,0 +1,133 @@
+// Copyright 2025 Tomas Machalek <tomas.machalek@gmail.com>
+// Copyright 2025 Institute of the Czech National Corpus,
+//                Faculty of Arts, Charles University
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing configures OpenTelemetry tracing. Instrumented
+// packages obtain their tracers via otel.Tracer which delegates
+// to the provider installed by Setup (a no-op one by default).
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/czcorpus/wsserver/config"
+	"github.com/czcorpus/wsserver/core"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ShutdownFunc flushes pending spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+func newExporter(ctx context.Context, conf config.TracingConf, stdout io.Writer) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case config.TracingExporterOTLP:
+		opts := make([]otlptracehttp.Option, 0, 2)
+		if conf.OTLPEndpoint != "" {
+			// otherwise, OTEL_EXPORTER_OTLP_* environment variables
+			// or the library default (localhost:4318) apply
+			opts = append(opts, otlptracehttp.WithEndpoint(conf.OTLPEndpoint))
+		}
+		if conf.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case config.TracingExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter '%s'", conf.Exporter)
+	}
+}
+
+// Setup installs a global tracer provider and W3C trace context
+// propagation. For the stdout exporter, spans are written to
+// the provided writer. In case tracing is disabled, a no-op
+// shutdown function is returned.
+func Setup(
+	ctx context.Context,
+	conf config.TracingConf,
+	serviceName, serviceVersion string,
+	stdout io.Writer,
+) (ShutdownFunc, error) {
+	if conf.Exporter == "" || conf.Exporter == config.TracingExporterNone {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporter(ctx, conf, stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing exporter: %w", err)
+	}
+	res, err := resource.New(
+		ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(serviceVersion),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// GinMiddleware creates a span for each request (except for the
+// excluded paths). Trace context is extracted from request headers.
+func GinMiddleware(serviceName string, excludedPaths ...string) gin.HandlerFunc {
+	return otelgin.Middleware(
+		serviceName,
+		otelgin.WithFilter(func(req *http.Request) bool {
+			return !slices.Contains(excludedPaths, req.URL.Path)
+		}),
+	)
+}
+
+// HTTPTransport wraps an HTTP client transport so each request
+// creates a client span and propagates trace context to the server.
+// A nil base means http.DefaultTransport.
+func HTTPTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// EndSpan records an application error (if any) and ends the span
+func EndSpan(span trace.Span, appErr core.AppError) {
+	if !appErr.IsZero() {
+		span.RecordError(appErr)
+		span.SetStatus(codes.Error, string(appErr.Type))
+	}
+	span.End()
+}